@@ -0,0 +1,234 @@
+// Package augment randomly transforms images at each pass over a dataset,
+// to train a network on more various samples
+package augment
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Augmenter randomly transforms images of width x height pixels (row major, one value per pixel).
+// Null parameters disable the matching transformation.
+type Augmenter struct {
+	Width, Height int
+	Shift         int     // Max translation in pixels
+	Rotation      float64 // Max rotation angle in degrees
+	Scale         float64 // Max zoom variation (0.1: zoom from 0.9 to 1.1)
+	Elastic       Elastic // Elastic distortion
+	Noise         float64 // Standard deviation of the gaussian noise
+}
+
+// Elastic distortion: each pixel is moved by a random displacement field smoothed by a gaussian filter
+type Elastic struct {
+	Alpha float64 // Intensity of the displacement in pixels
+	Sigma float64 // Standard deviation of the gaussian filter in pixels
+}
+
+// params of one transformation
+type params struct {
+	dx, dy float64   // Translation
+	angle  float64   // Rotation in radians
+	zoom   float64   // Scale factor
+	fx, fy []float64 // Displacement field (optional)
+}
+
+// check the parameters
+func (a Augmenter) check() error {
+	switch {
+	case a.Width <= 0 || a.Height <= 0:
+		return fmt.Errorf("width and height shall be positive")
+	case a.Shift < 0 || a.Rotation < 0 || a.Noise < 0:
+		return fmt.Errorf("shift, rotation and noise shall not be negative")
+	case a.Scale < 0 || a.Scale >= 1:
+		return fmt.Errorf("scale %v shall be in [0, 1)", a.Scale)
+	case a.Elastic.Alpha < 0 || a.Elastic.Sigma < 0:
+		return fmt.Errorf("elastic alpha and sigma shall not be negative")
+	}
+	return nil
+}
+
+// Apply randomly transforms one image
+func (a Augmenter) Apply(x []float64, r *rand.Rand) ([]float64, error) {
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	if len(x) != a.Width*a.Height {
+		return nil, fmt.Errorf("%d values found, %dx%d image expected", len(x), a.Width, a.Height)
+	}
+
+	p := params{zoom: 1}
+	if a.Shift > 0 {
+		p.dx = float64(r.Intn(2*a.Shift+1) - a.Shift)
+		p.dy = float64(r.Intn(2*a.Shift+1) - a.Shift)
+	}
+	if a.Rotation != 0 {
+		p.angle = (2*r.Float64() - 1) * a.Rotation * math.Pi / 180
+	}
+	if a.Scale != 0 {
+		p.zoom = 1 + (2*r.Float64()-1)*a.Scale
+	}
+	if a.Elastic.Alpha != 0 {
+		p.fx = a.field(r)
+		p.fy = a.field(r)
+	}
+
+	y := a.transform(x, p)
+	if a.Noise != 0 {
+		for i := range y {
+			y[i] += r.NormFloat64() * a.Noise
+		}
+	}
+	return y, nil
+}
+
+// transform computes each pixel from its position in the source image (bilinear interpolation)
+func (a Augmenter) transform(x []float64, p params) []float64 {
+	cx, cy := float64(a.Width-1)/2, float64(a.Height-1)/2
+	cos, sin := math.Cos(p.angle), math.Sin(p.angle)
+
+	y := make([]float64, len(x))
+	for i := 0; i < a.Height; i++ {
+		for j := 0; j < a.Width; j++ {
+			// Inverse transformation: translation, rotation then zoom
+			u := float64(j) - cx - p.dx
+			v := float64(i) - cy - p.dy
+			sx := (cos*u+sin*v)/p.zoom + cx
+			sy := (-sin*u+cos*v)/p.zoom + cy
+			if p.fx != nil {
+				sx += p.fx[i*a.Width+j]
+				sy += p.fy[i*a.Width+j]
+			}
+			y[i*a.Width+j] = a.interpolate(x, sx, sy)
+		}
+	}
+	return y
+}
+
+// interpolate the value at a given position, pixels outside the image are null
+func (a Augmenter) interpolate(x []float64, sx, sy float64) float64 {
+	j0, i0 := int(math.Floor(sx)), int(math.Floor(sy))
+	tx, ty := sx-float64(j0), sy-float64(i0)
+	pixel := func(i, j int) float64 {
+		if i < 0 || i >= a.Height || j < 0 || j >= a.Width {
+			return 0
+		}
+		return x[i*a.Width+j]
+	}
+	return (1-ty)*((1-tx)*pixel(i0, j0)+tx*pixel(i0, j0+1)) +
+		ty*((1-tx)*pixel(i0+1, j0)+tx*pixel(i0+1, j0+1))
+}
+
+// field builds a random displacement field smoothed by a gaussian filter
+func (a Augmenter) field(r *rand.Rand) []float64 {
+	f := make([]float64, a.Width*a.Height)
+	for i := range f {
+		f[i] = 2*r.Float64() - 1
+	}
+	f = a.smooth(f)
+
+	// Normalize so that alpha is the max displacement
+	var max float64
+	for _, v := range f {
+		max = math.Max(max, math.Abs(v))
+	}
+	if max == 0 {
+		return f
+	}
+	for i := range f {
+		f[i] *= a.Elastic.Alpha / max
+	}
+	return f
+}
+
+// smooth applies a separable gaussian filter
+func (a Augmenter) smooth(f []float64) []float64 {
+	sigma := a.Elastic.Sigma
+	if sigma <= 0 {
+		return f
+	}
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	for k := range kernel {
+		d := float64(k - radius)
+		kernel[k] = math.Exp(-d * d / (2 * sigma * sigma))
+	}
+
+	convolve := func(src []float64, di, dj int) []float64 {
+		dst := make([]float64, len(src))
+		for i := 0; i < a.Height; i++ {
+			for j := 0; j < a.Width; j++ {
+				var sum, weight float64
+				for k, w := range kernel {
+					ii, jj := i+(k-radius)*di, j+(k-radius)*dj
+					if ii < 0 || ii >= a.Height || jj < 0 || jj >= a.Width {
+						continue
+					}
+					sum += w * src[ii*a.Width+jj]
+					weight += w
+				}
+				dst[i*a.Width+j] = sum / weight
+			}
+		}
+		return dst
+	}
+	return convolve(convolve(f, 0, 1), 1, 0)
+}
+
+// dataset applies random transformations to the inputs of a dataset
+type dataset struct {
+	augmenter Augmenter
+	ds        mlp.Dataset
+	net       *mlp.Network // Random source of the seed of each pass
+}
+
+// Dataset wraps a dataset of images so that inputs are transformed on the fly, outputs are kept.
+// Each pass (each epoch) uses new random transformations seeded by the random source of the trained network:
+// the sequence of passes only depends on the network seed, and is saved in checkpoints.
+func (a Augmenter) Dataset(net *mlp.Network, ds mlp.Dataset) (mlp.Dataset, error) {
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	return &dataset{
+		augmenter: a,
+		ds:        ds,
+		net:       net,
+	}, nil
+}
+
+func (ds *dataset) Open() (mlp.Iterator, error) {
+	it, err := ds.ds.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &iterator{
+		augmenter: ds.augmenter,
+		it:        it,
+		random:    rand.New(mlp.NewSource(ds.net.Rand().Int63())),
+	}, nil
+}
+
+// iterator transforms the inputs of one pass
+type iterator struct {
+	augmenter Augmenter
+	it        mlp.Iterator
+	random    *rand.Rand
+}
+
+func (it *iterator) Next() ([]float64, []float64, error) {
+	x, y, err := it.it.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	x, err = it.augmenter.Apply(x, it.random)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, y, nil
+}
+
+func (it *iterator) Close() error {
+	return it.it.Close()
+}
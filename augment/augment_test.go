@@ -0,0 +1,205 @@
+package augment
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// image of 4x3 pixels
+var image = []float64{
+	0, 0, 0, 0,
+	0, 1, 2, 0,
+	0, 0, 0, 0,
+}
+
+func sum(x []float64) float64 {
+	var s float64
+	for _, v := range x {
+		s += v
+	}
+	return s
+}
+
+func TestAugmenter(t *testing.T) {
+	Convey("augmenter", t, func() {
+		a := Augmenter{Width: 4, Height: 3}
+
+		Convey("identity", func() {
+			y, err := a.Apply(image, rand.New(rand.NewSource(1)))
+			So(err, ShouldBeNil)
+			So(y, ShouldResemble, image)
+
+			_, err = a.Apply(image[1:], rand.New(rand.NewSource(1)))
+			So(err, ShouldBeError, "11 values found, 4x3 image expected")
+		})
+
+		Convey("transform", func() {
+			// Shift
+			y := a.transform(image, params{dx: 1, dy: -1, zoom: 1})
+			So(y, ShouldResemble, []float64{
+				0, 0, 1, 2,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+			})
+
+			// Half rotation around the center
+			y = a.transform(image, params{angle: math.Pi, zoom: 1})
+			expected := []float64{
+				0, 0, 0, 0,
+				0, 2, 1, 0,
+				0, 0, 0, 0,
+			}
+			for i := range y {
+				So(y[i], ShouldAlmostEqual, expected[i])
+			}
+
+			// Zoom in: borders are interpolated
+			y = a.transform([]float64{0, 0, 0, 0, 0, 4, 4, 0, 0, 0, 0, 0}, params{zoom: 2})
+			So(y[5], ShouldEqual, 4)
+			So(y[6], ShouldEqual, 4)
+			So(y[4], ShouldEqual, 3)
+		})
+
+		Convey("random transformations", func() {
+			a = Augmenter{
+				Width: 4, Height: 3,
+				Shift: 1, Rotation: 10, Scale: 0.1,
+				Elastic: Elastic{Alpha: 0.5, Sigma: 1},
+				Noise:   0.01,
+			}
+			y1, err := a.Apply(image, rand.New(rand.NewSource(1)))
+			So(err, ShouldBeNil)
+			y2, err := a.Apply(image, rand.New(rand.NewSource(1)))
+			So(err, ShouldBeNil)
+			y3, err := a.Apply(image, rand.New(rand.NewSource(2)))
+			So(err, ShouldBeNil)
+			So(y1, ShouldResemble, y2)
+			So(y1, ShouldNotResemble, y3)
+			So(image[5], ShouldEqual, 1) // not modified
+
+			// Elastic displacement does not exceed alpha
+			f := a.field(rand.New(rand.NewSource(1)))
+			for _, v := range f {
+				So(math.Abs(v), ShouldBeLessThanOrEqualTo, 0.5)
+			}
+		})
+
+		Convey("invalid parameters", func() {
+			invalid := map[string]Augmenter{
+				"width and height shall be positive":              {Width: 0, Height: 3},
+				"shift, rotation and noise shall not be negative": {Width: 4, Height: 3, Rotation: -10},
+				"scale 1 shall be in [0, 1)":                      {Width: 4, Height: 3, Scale: 1},
+				"scale -0.1 shall be in [0, 1)":                   {Width: 4, Height: 3, Scale: -0.1},
+				"elastic alpha and sigma shall not be negative":   {Width: 4, Height: 3, Elastic: Elastic{Alpha: 1, Sigma: -1}},
+			}
+			net := mlp.NewNetwork(0.1, 12)
+			for expected, b := range invalid {
+				_, err := b.Apply(image, rand.New(rand.NewSource(1)))
+				So(err, ShouldBeError, expected)
+				_, err = b.Dataset(&net, mlp.Slices(nil, nil))
+				So(err, ShouldBeError, expected)
+			}
+		})
+
+		Convey("dataset", func() {
+			a = Augmenter{Width: 4, Height: 3, Shift: 1}
+			xData := [][]float64{image, image}
+			yData := [][]float64{{1}, {0}}
+
+			// readPasses reads the inputs of several passes
+			readPasses := func(ds mlp.Dataset, passes int) [][]float64 {
+				var inputs [][]float64
+				for pass := 0; pass < passes; pass++ {
+					it, err := ds.Open()
+					So(err, ShouldBeNil)
+					for i := range xData {
+						x, y, err := it.Next()
+						So(err, ShouldBeNil)
+						So(y, ShouldResemble, yData[i])
+						So(sum(x), ShouldEqual, 3) // shifted, but still in the image
+						inputs = append(inputs, x)
+					}
+					_, _, err = it.Next()
+					So(err, ShouldEqual, io.EOF)
+					So(it.Close(), ShouldBeNil)
+				}
+				return inputs
+			}
+
+			net1, net2 := mlp.NewNetwork(0.1, 12), mlp.NewNetwork(0.1, 12)
+			net1.Seed(42)
+			net2.Seed(42)
+			ds1, err := a.Dataset(&net1, mlp.Slices(xData, yData))
+			So(err, ShouldBeNil)
+			ds2, err := a.Dataset(&net2, mlp.Slices(xData, yData))
+			So(err, ShouldBeNil)
+			inputs1 := readPasses(ds1, 3)
+			inputs2 := readPasses(ds2, 3)
+			So(inputs1, ShouldResemble, inputs2)
+			So(inputs1[:2], ShouldNotResemble, inputs1[2:4])
+
+			// Wrong size
+			ds3, err := a.Dataset(&net1, mlp.Slices([][]float64{{1}}, [][]float64{{1}}))
+			So(err, ShouldBeNil)
+			it, err := ds3.Open()
+			So(err, ShouldBeNil)
+			_, _, err = it.Next()
+			So(err, ShouldBeError, "1 values found, 4x3 image expected")
+		})
+
+		Convey("resume", func() {
+			a = Augmenter{Width: 4, Height: 3, Shift: 1, Noise: 0.1}
+			ctx := context.Background()
+			ds := mlp.Slices([][]float64{image, image}, [][]float64{{1}, {0}})
+			newNet := func() mlp.Network {
+				rand.Seed(42)
+				net := mlp.NewNetwork(0.3, 12)
+				net.AddLayer(mlp.LinearBuilder{}, 1, mlp.Sigmoid{})
+				net.Seed(7)
+				return net
+			}
+
+			// Full run
+			net1 := newNet()
+			net1.Stop.OnEpoch(8)
+			ds1, err := a.Dataset(&net1, ds)
+			So(err, ShouldBeNil)
+			term1, err := net1.TrainDataset(ctx, ds1)
+			So(err, ShouldBeNil)
+
+			// Interrupted run
+			dir := t.TempDir()
+			net2 := newNet()
+			net2.Checkpoint.Every(4)
+			net2.Checkpoint.ToDir(dir, 0)
+			net2.Stop.OnEpoch(4)
+			ds2, err := a.Dataset(&net2, ds)
+			So(err, ShouldBeNil)
+			_, err = net2.TrainDataset(ctx, ds2)
+			So(err, ShouldBeNil)
+
+			// Resume: same transformations as the full run
+			file, err := os.Open(filepath.Join(dir, "checkpoint-000003.json"))
+			So(err, ShouldBeNil)
+			defer file.Close()
+			var net3 mlp.Network
+			net3.Stop.OnEpoch(8)
+			ds3, err := a.Dataset(&net3, ds)
+			So(err, ShouldBeNil)
+			term3, err := net3.ResumeDataset(ctx, file, ds3)
+			So(err, ShouldBeNil)
+			So(term3.History(), ShouldHaveLength, len(term1.History()))
+			So(term3.History().Last().MeanSquaredError, ShouldEqual, term1.History().Last().MeanSquaredError)
+			So(net3.Predict(image), ShouldResemble, net1.Predict(image))
+		})
+	})
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/sbiemont/simlpe/data"
+	"github.com/sbiemont/simlpe/mlp"
+	"github.com/sbiemont/simlpe/serve"
+)
+
+// train a network from a configuration file and write the model
+func train(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("train", flag.ContinueOnError)
+	configPath := flags.String("config", "", "json configuration file")
+	dataPath := flags.String("data", "", "training data (csv, tsv or idx images)")
+	labelsPath := flags.String("labels", "", "idx labels file (if data are idx images)")
+	targets := flags.Int("targets", 1, "number of output columns (last csv columns)")
+	validationPath := flags.String("validation", "", "optional validation data (same format)")
+	validationLabelsPath := flags.String("validation-labels", "", "idx labels file of the validation data")
+	outPath := flags.String("out", "model.json", "output model file")
+	logPath := flags.String("log", "", "optional json lines training log")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := readConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	net, err := cfg.network()
+	if err != nil {
+		return err
+	}
+	xData, yData, err := loadData(*dataPath, *labelsPath, *targets)
+	if err != nil {
+		return err
+	}
+	if *validationPath != "" {
+		xValid, yValid, err := loadData(*validationPath, *validationLabelsPath, *targets)
+		if err != nil {
+			return err
+		}
+		net.SetValidation(xValid, yValid)
+	}
+	if *logPath != "" {
+		file, err := os.Create(*logPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		net.SetLogger(mlp.NewRunLogger(file))
+	}
+
+	term, err := net.Train(context.Background(), xData, yData)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, term)
+	return writeModel(*outPath, net)
+}
+
+// predict computes the outputs of each csv input row
+func predict(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("predict", flag.ContinueOnError)
+	modelPath := flags.String("model", "model.json", "model file")
+	dataPath := flags.String("data", "", "input csv / tsv file with a header (default: stdin)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	net, err := readModel(*modelPath)
+	if err != nil {
+		return err
+	}
+
+	input, comma := stdin, ','
+	if *dataPath != "" {
+		file, err := os.Open(*dataPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input, comma = file, data.Separator(*dataPath)
+	}
+	xData, _, err := readCSV(input, comma, 0)
+	if err != nil {
+		return err
+	}
+
+	// Write outputs
+	writer := csv.NewWriter(stdout)
+	for i, xi := range xData {
+		y := net.Predict(xi)
+		if i == 0 {
+			header := make([]string, len(y))
+			for j := range y {
+				header[j] = fmt.Sprintf("y%d", j)
+			}
+			writer.Write(header)
+		}
+		row := make([]string, len(y))
+		for j, yj := range y {
+			row[j] = strconv.FormatFloat(yj, 'g', -1, 64)
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// eval computes the error and the accuracy of a model on labeled data
+func eval(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	modelPath := flags.String("model", "model.json", "model file")
+	dataPath := flags.String("data", "", "labeled data (csv, tsv or idx images)")
+	labelsPath := flags.String("labels", "", "idx labels file (if data are idx images)")
+	targets := flags.Int("targets", 1, "number of output columns (last csv columns)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	net, err := readModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	xData, yData, err := loadData(*dataPath, *labelsPath, *targets)
+	if err != nil {
+		return err
+	}
+
+	evaluation, err := net.Evaluate(xData, yData)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "samples: %d\nerror: %g\naccuracy: %g\n",
+		len(xData), evaluation.MeanSquaredError, evaluation.Accuracy)
+	return nil
+}
+
+// inspect prints a summary of a model
+func inspect(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	modelPath := flags.String("model", "model.json", "model file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	net, err := readModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(stdout, net.Summary())
+	return nil
+}
+
+// serveModel serves the predictions of a model over http, reloading the model file when modified
+func serveModel(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	modelPath := flags.String("model", "model.json", "model file")
+	addr := flags.String("addr", ":8080", "listening address")
+	timeout := flags.Duration("timeout", 5*time.Second, "max duration of a request")
+	reload := flags.Duration("reload", 2*time.Second, "interval between model file checks (0: no reload)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	srv, err := serve.New(*modelPath, serve.Options{Timeout: *timeout})
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *reload > 0 {
+		go srv.Watch(ctx, *reload, func(err error) {
+			fmt.Fprintln(stdout, "reload:", err)
+		})
+	}
+
+	server := &http.Server{Addr: *addr, Handler: srv}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	fmt.Fprintf(stdout, "serving %s on %s\n", *modelPath, *addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// readModel loads a network
+func readModel(path string) (mlp.Network, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return mlp.Network{}, err
+	}
+	net := mlp.Network{}
+	err = net.UnmarshalJSON(content)
+	return net, err
+}
+
+// writeModel saves a network
+func writeModel(path string, net mlp.Network) error {
+	content, err := net.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
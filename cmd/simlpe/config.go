@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// config of a training: the network spec and the random seed
+type config struct {
+	mlp.Spec
+	Seed int64 `json:"seed"`
+}
+
+// readConfig loads a json configuration file
+func readConfig(path string) (config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return config{}, err
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	var cfg config
+	err = dec.Decode(&cfg)
+	if err != nil {
+		return config{}, fmt.Errorf("cannot read config %q: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// network builds the network described by the configuration
+func (cfg config) network() (mlp.Network, error) {
+	rand.Seed(cfg.Seed)
+	net, err := mlp.BuildFromSpec(cfg.Spec)
+	if err != nil {
+		return mlp.Network{}, err
+	}
+	net.Seed(cfg.Seed)
+	return net, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sbiemont/simlpe/data"
+	"github.com/sbiemont/simlpe/idx"
+)
+
+// loadData reads input and output data
+//   - from an idx images file and its labels file (one-hot outputs, inputs scaled to [0, 1])
+//   - or from a csv / tsv file with a header, the last "targets" columns being the outputs
+func loadData(path, labels string, targets int) ([][]float64, [][]float64, error) {
+	if labels != "" {
+		return readIDX(path, labels)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	return readCSV(file, data.Separator(path), targets)
+}
+
+// readCSV reads a csv with a header, the last "targets" columns being the outputs
+func readCSV(r io.Reader, comma rune, targets int) ([][]float64, [][]float64, error) {
+	table, err := data.ReadCSV(r, comma)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(table.Rows) == 0 {
+		return nil, nil, fmt.Errorf("at least one row expected")
+	}
+	features := len(table.Header) - targets
+	if targets < 0 || features <= 0 {
+		return nil, nil, fmt.Errorf("cannot select %d targets in %d columns", targets, len(table.Header))
+	}
+
+	pipeline := data.NewPipeline(table.Header[:features], table.Header[features:])
+	return pipeline.FitTransform(table)
+}
+
+// readIDX reads idx images and labels files (optionally gzip compressed)
+func readIDX(pathImages, pathLabels string) ([][]float64, [][]float64, error) {
+	_, images, err := idx.ReadAll(pathImages)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, labels, err := idx.ReadAll(pathLabels)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.RecordSize() != 1 {
+		return nil, nil, fmt.Errorf("%s: one label per record expected", pathLabels)
+	}
+	if len(images) != len(labels) {
+		return nil, nil, fmt.Errorf("%d images and %d labels found", len(images), len(labels))
+	}
+
+	// Number of classes
+	classes := 0
+	for _, label := range labels {
+		if label[0] < 0 {
+			return nil, nil, fmt.Errorf("%s: negative label found", pathLabels)
+		}
+		if int(label[0]) >= classes {
+			classes = int(label[0]) + 1
+		}
+	}
+
+	xData := images
+	yData := make([][]float64, len(labels))
+	for i, image := range images {
+		for j := range image {
+			image[j] /= 255.0
+		}
+		yData[i] = make([]float64, classes)
+		yData[i][int(labels[i][0])] = 1
+	}
+	return xData, yData, nil
+}
@@ -0,0 +1,55 @@
+// Command simlpe trains, runs and inspects networks without writing go code
+//
+//	simlpe train   -config config.json -data train.csv -targets 1 -out model.json
+//	simlpe predict -model model.json < inputs.csv
+//	simlpe eval    -model model.json -data test.csv -targets 1
+//	simlpe inspect -model model.json
+//	simlpe serve   -model model.json -addr :8080
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: simlpe <command> [flags]
+
+commands:
+  train    train a network from a configuration file
+  predict  compute outputs of a network for each input row
+  eval     evaluate a network on labeled data
+  inspect  print a summary of a network
+  serve    serve the predictions of a network over http
+
+run "simlpe <command> -h" for the flags of a command`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run the command found in args
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "train":
+		return train(args[1:], stdout)
+	case "predict":
+		return predict(args[1:], stdin, stdout)
+	case "eval":
+		return eval(args[1:], stdout)
+	case "inspect":
+		return inspect(args[1:], stdout)
+	case "serve":
+		return serveModel(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
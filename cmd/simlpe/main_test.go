@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCommands(t *testing.T) {
+	Convey("simlpe", t, func() {
+		dir := t.TempDir()
+		path := func(name string) string { return filepath.Join(dir, name) }
+		write := func(name, content string) {
+			So(os.WriteFile(path(name), []byte(content), 0o644), ShouldBeNil)
+		}
+
+		write("config.json", `{
+			"inputs": 2,
+			"optimizer": {"learning-rate": 0.3},
+			"seed": 42,
+			"layers": [
+				{"neurons": 3, "activator": "sigmoid"},
+				{"neurons": 1, "activator": "sigmoid"}
+			],
+			"stop": {"epoch": 10000, "error": 0.0001}
+		}`)
+		write("xor.csv", "a,b,xor\n0,0,0\n1,0,1\n0,1,1\n1,1,0\n")
+
+		Convey("train, eval, predict, inspect", func() {
+			var out bytes.Buffer
+			err := run([]string{
+				"train", "-config", path("config.json"), "-data", path("xor.csv"), "-out", path("model.json"), "-log", path("run.jsonl"),
+			}, nil, &out)
+			So(err, ShouldBeNil)
+			So(out.String(), ShouldContainSubstring, "reached")
+			logs, err := os.Open(path("run.jsonl"))
+			So(err, ShouldBeNil)
+			defer logs.Close()
+			runLog, err := mlp.ReadRunLog(logs)
+			So(err, ShouldBeNil)
+			So(runLog.History, ShouldNotBeEmpty)
+			So(runLog.Reached, ShouldNotBeEmpty)
+
+			out.Reset()
+			err = run([]string{"eval", "-model", path("model.json"), "-data", path("xor.csv")}, nil, &out)
+			So(err, ShouldBeNil)
+			So(out.String(), ShouldContainSubstring, "accuracy: 1\n")
+
+			out.Reset()
+			err = run([]string{"predict", "-model", path("model.json")}, strings.NewReader("a,b\n0,0\n1,0\n"), &out)
+			So(err, ShouldBeNil)
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			So(lines, ShouldHaveLength, 3)
+			So(lines[0], ShouldEqual, "y0")
+
+			out.Reset()
+			err = run([]string{"inspect", "-model", path("model.json")}, nil, &out)
+			So(err, ShouldBeNil)
+			So(out.String(), ShouldContainSubstring, "total parameters: 13")
+		})
+
+		Convey("errors", func() {
+			So(run(nil, nil, nil), ShouldNotBeNil)
+			So(run([]string{"unknown"}, nil, nil), ShouldNotBeNil)
+
+			write("bad.json", `{
+				"inputs": 2,
+				"optimizer": {"learning-rate": 0.3},
+				"layers": [{"neurons": 1, "activator": "unknown"}]
+			}`)
+			err := run([]string{"train", "-config", path("bad.json"), "-data", path("xor.csv")}, nil, nil)
+			So(err, ShouldBeError, `layer 0: unknown activator "unknown"`)
+
+			err = run([]string{"eval", "-model", path("config.json"), "-data", path("xor.csv"), "-targets", "3"}, nil, nil)
+			So(err, ShouldNotBeNil)
+
+			err = run([]string{"serve", "-model", path("missing.json")}, nil, nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,150 @@
+// Package codegen writes a standalone go source file computing the predictions of a trained network:
+// weights are stored in arrays and activators are inlined, the generated code does not import mlp
+// and gives the same outputs as Network.Predict
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Options of the generated code
+type Options struct {
+	Package string // Package name (default "model")
+	Func    string // Prediction function name (default "Predict")
+}
+
+// activators lists the inlined expression of each activator, "v" being the value to activate
+// (same computation as the mlp activators)
+var activators = map[string][]string{
+	"sigmoid": {"v = 1 / (1 + math.Exp(-v))"},
+	"htan":    {"exp := math.Exp(2 * v)", "v = (exp - 1) / (exp + 1)"},
+	"relu":    {"v = math.Max(0.0, v)"},
+}
+
+// Generate writes the go source file of the network
+func Generate(w io.Writer, net mlp.Network, opts Options) error {
+	if opts.Package == "" {
+		opts.Package = "model"
+	}
+	if opts.Func == "" {
+		opts.Func = "Predict"
+	}
+	if scaler := net.Scaler(); scaler != nil {
+		return fmt.Errorf("scaler %q is not supported, scale the inputs before the prediction", scaler.Type())
+	}
+	layers := net.Layers()
+	if len(layers) == 0 {
+		return fmt.Errorf("at least one layer expected")
+	}
+
+	var body, vars bytes.Buffer
+	in := net.Neurons()[0]
+	fmt.Fprintf(&body, "// %s computes the outputs of the network\n", opts.Func)
+	fmt.Fprintf(&body, "func %s(x [%d]float64) [%d]float64 {\n", opts.Func, in, net.Neurons()[len(net.Neurons())-1])
+	fmt.Fprintf(&body, "y0 := x\n")
+	var useMath bool
+	for k, layer := range layers {
+		switch l := layer.(type) {
+		case mlp.Linear:
+			// y = x.w + b, summed in the same order as mlp.Linear
+			nin, out := l.Shape()
+			if nin != in {
+				return fmt.Errorf("layer %d: %d inputs found, %d expected", k, nin, in)
+			}
+			if err := writeMatrix(&vars, fmt.Sprintf("weights%d", k), l.Weights()); err != nil {
+				return fmt.Errorf("layer %d: %w", k, err)
+			}
+			if err := writeVector(&vars, fmt.Sprintf("biases%d", k), l.Biases()); err != nil {
+				return fmt.Errorf("layer %d: %w", k, err)
+			}
+			fmt.Fprintf(&body, "\n// Layer %d: linear\n", k)
+			fmt.Fprintf(&body, "var y%d [%d]float64\n", k+1, out)
+			fmt.Fprintf(&body, "for i := range y%d {\nfor j := range y%d {\ny%d[j] += y%d[i] * weights%d[i][j]\n}\n}\n", k, k+1, k+1, k, k)
+			fmt.Fprintf(&body, "for j := range y%d {\ny%d[j] += biases%d[j]\n}\n", k+1, k+1, k)
+			in = out
+
+		case mlp.ActivatorLayer:
+			name := l.Activator().String()
+			lines, ok := activators[name]
+			if !ok {
+				return fmt.Errorf("layer %d: activator %q is not supported", k, name)
+			}
+			useMath = true
+			fmt.Fprintf(&body, "\n// Layer %d: %s\n", k, name)
+			fmt.Fprintf(&body, "y%d := y%d\n", k+1, k)
+			fmt.Fprintf(&body, "for j, v := range y%d {\n", k+1)
+			for _, line := range lines {
+				fmt.Fprintln(&body, line)
+			}
+			fmt.Fprintf(&body, "y%d[j] = v\n}\n", k+1)
+
+		default:
+			return fmt.Errorf("layer %d: type %q is not supported", k, layer.Type())
+		}
+	}
+	fmt.Fprintf(&body, "return y%d\n}\n", len(layers))
+
+	var src bytes.Buffer
+	fmt.Fprintln(&src, "// Code generated by simlpe codegen. DO NOT EDIT.")
+	fmt.Fprintf(&src, "\npackage %s\n\n", opts.Package)
+	if useMath {
+		fmt.Fprintln(&src, `import "math"`)
+	}
+	src.Write(body.Bytes())
+	src.Write(vars.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// writeMatrix writes the values as a 2D array
+func writeMatrix(w io.Writer, name string, values [][]float64) error {
+	fmt.Fprintf(w, "\nvar %s = [%d][%d]float64{\n", name, len(values), len(values[0]))
+	for _, row := range values {
+		str, err := formatRow(name, row)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s,\n", str)
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// writeVector writes the values as a 1D array
+func writeVector(w io.Writer, name string, values []float64) error {
+	str, err := formatRow(name, values)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\nvar %s = [%d]float64%s\n", name, len(values), str)
+	return err
+}
+
+// formatRow formats the values (exact decimal representation)
+func formatRow(name string, row []float64) (string, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for j, v := range row {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", fmt.Errorf("%s: non finite value", name)
+		}
+		if j > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
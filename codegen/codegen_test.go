@@ -0,0 +1,120 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// run compiles and runs the generated code, printing the bits of each output of the inputs
+func run(dir string, src []byte, inputs [][]float64) ([]uint64, error) {
+	var main bytes.Buffer
+	fmt.Fprintln(&main, "package main\n\nimport (\n\"fmt\"\n\"math\"\n\n\"gen/model\"\n)\n\nfunc main() {")
+	for _, x := range inputs {
+		values := make([]string, len(x))
+		for i, v := range x {
+			values[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		fmt.Fprintf(&main, "for _, v := range model.Predict([%d]float64{%s}) {\nfmt.Println(math.Float64bits(v))\n}\n",
+			len(x), strings.Join(values, ", "))
+	}
+	fmt.Fprintln(&main, "}")
+
+	files := map[string][]byte{
+		"go.mod":         []byte("module gen\n\ngo 1.21\n"),
+		"main.go":        main.Bytes(),
+		"model/model.go": src,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, out)
+	}
+
+	var bits []uint64
+	for _, line := range strings.Fields(string(out)) {
+		b, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		bits = append(bits, b)
+	}
+	return bits, nil
+}
+
+func TestGenerate(t *testing.T) {
+	Convey("codegen", t, func() {
+		rand.Seed(42)
+		net := mlp.NewNetwork(0.3, 3)
+		net.AddLayer(mlp.LinearBuilder{}, 5, mlp.Htan{})
+		net.AddLayer(mlp.LinearBuilder{}, 4, mlp.ReLU{})
+		net.AddLayer(mlp.LinearBuilder{}, 2, mlp.Sigmoid{})
+
+		Convey("source", func() {
+			var buf bytes.Buffer
+			So(Generate(&buf, net, Options{Package: "nnet", Func: "Run"}), ShouldBeNil)
+			src := buf.String()
+			So(src, ShouldStartWith, "// Code generated by simlpe codegen. DO NOT EDIT.\n\npackage nnet\n")
+			So(src, ShouldContainSubstring, "func Run(x [3]float64) [2]float64 {")
+			So(src, ShouldContainSubstring, "var weights0 = [3][5]float64{")
+			So(src, ShouldContainSubstring, "var biases4 = [2]float64{0, 0}")
+			So(src, ShouldNotContainSubstring, "simlpe/mlp")
+		})
+
+		Convey("same outputs as the network", func() {
+			if _, err := exec.LookPath("go"); err != nil {
+				t.Skip("go command not found")
+			}
+
+			var inputs [][]float64
+			for i := 0; i < 20; i++ {
+				inputs = append(inputs, []float64{rand.NormFloat64(), rand.NormFloat64(), 10 * rand.NormFloat64()})
+			}
+			var expected []uint64
+			for _, x := range inputs {
+				for _, y := range net.Predict(x) {
+					expected = append(expected, math.Float64bits(y))
+				}
+			}
+
+			var buf bytes.Buffer
+			So(Generate(&buf, net, Options{}), ShouldBeNil)
+			bits, err := run(t.TempDir(), buf.Bytes(), inputs)
+			So(err, ShouldBeNil)
+			So(bits, ShouldResemble, expected)
+		})
+
+		Convey("errors", func() {
+			So(Generate(&bytes.Buffer{}, mlp.NewNetwork(0.3, 2), Options{}), ShouldBeError, "at least one layer expected")
+
+			So(net.SetScaler(mlp.Normalize{}), ShouldBeNil)
+			So(Generate(&bytes.Buffer{}, net, Options{}), ShouldBeError, `scaler "normalize" is not supported, scale the inputs before the prediction`)
+
+			_, err := formatRow("w", []float64{1, math.NaN()})
+			So(err, ShouldBeError, "w: non finite value")
+		})
+	})
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// csvDataset streams the rows of a csv file
+type csvDataset struct {
+	path     string
+	pipeline Pipeline
+}
+
+// NewCSVDataset streams the rows of a csv (or tsv) file converted by a fitted pipeline,
+// the file is read again at each pass
+func NewCSVDataset(path string, pipeline Pipeline) mlp.Dataset {
+	return csvDataset{
+		path:     path,
+		pipeline: pipeline,
+	}
+}
+
+func (ds csvDataset) Open() (mlp.Iterator, error) {
+	if !ds.pipeline.Fitted {
+		return nil, fmt.Errorf("pipeline shall be fitted")
+	}
+
+	file, err := os.Open(ds.path)
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(file)
+	reader.Comma = Separator(ds.path)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: cannot read header: %w", ds.path, err)
+	}
+
+	columns := append(append([]Column{}, ds.pipeline.Features...), ds.pipeline.Targets...)
+	indexes, err := indexesOf(Table{Header: header}, columns)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &csvIterator{
+		file:     file,
+		reader:   reader,
+		columns:  columns,
+		indexes:  indexes,
+		features: encodedWidth(ds.pipeline.Features),
+	}, nil
+}
+
+// csvIterator reads the rows of a csv file
+type csvIterator struct {
+	file     *os.File
+	reader   *csv.Reader
+	columns  []Column
+	indexes  []int
+	features int // Number of input values
+	row      int
+}
+
+func (it *csvIterator) Next() ([]float64, []float64, error) {
+	for {
+		record, err := it.reader.Read()
+		if err == io.EOF {
+			return nil, nil, io.EOF
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		it.row++
+
+		values, kept, err := encodeRow(record, it.columns, it.indexes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", it.row, err)
+		}
+		if kept {
+			return values[:it.features:it.features], values[it.features:], nil
+		}
+	}
+}
+
+func (it *csvIterator) Close() error {
+	return it.file.Close()
+}
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCSVDataset(t *testing.T) {
+	Convey("csv dataset", t, func() {
+		content := "age\tcountry\tlabel\n" +
+			"10\tfr\tyes\n" +
+			"NA\tus\tno\n" +
+			"30\t\t?\n" +
+			"40\tfr\tyes\n"
+		path := filepath.Join(t.TempDir(), "data.tsv")
+		So(os.WriteFile(path, []byte(content), 0o644), ShouldBeNil)
+
+		table, err := ReadCSV(strings.NewReader(content), '\t')
+		So(err, ShouldBeNil)
+		p := Pipeline{
+			Features: []Column{
+				{Name: "age", Missing: Median},
+				{Name: "country", Categorical: true, Missing: Mean},
+			},
+			Targets: []Column{{Name: "label", Categorical: true, Missing: Drop}},
+		}
+
+		Convey("not fitted", func() {
+			_, err := NewCSVDataset(path, p).Open()
+			So(err, ShouldBeError, "pipeline shall be fitted")
+		})
+
+		Convey("same as transform", func() {
+			xData, yData, err := p.FitTransform(table)
+			So(err, ShouldBeNil)
+
+			for pass := 0; pass < 2; pass++ {
+				it, err := NewCSVDataset(path, p).Open()
+				So(err, ShouldBeNil)
+				for i := range xData {
+					x, y, err := it.Next()
+					So(err, ShouldBeNil)
+					So(x, ShouldResemble, xData[i])
+					So(y, ShouldResemble, yData[i])
+				}
+				_, _, err = it.Next()
+				So(err, ShouldEqual, io.EOF)
+				So(it.Close(), ShouldBeNil)
+			}
+		})
+
+		Convey("errors", func() {
+			_, _, err := p.FitTransform(table)
+			So(err, ShouldBeNil)
+
+			_, err = NewCSVDataset(filepath.Join(t.TempDir(), "missing.csv"), p).Open()
+			So(err, ShouldNotBeNil)
+
+			p.Features[0].Name = "unknown"
+			_, err = NewCSVDataset(path, p).Open()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
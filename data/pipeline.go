@@ -0,0 +1,324 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Missing is the strategy applied to missing values (empty, "NA", "NaN", "null", "?")
+type Missing string
+
+const (
+	Fail     Missing = ""         // Missing values are errors
+	Drop     Missing = "drop"     // Rows with missing values are removed
+	Mean     Missing = "mean"     // Replaced by the mean (most frequent category if categorical)
+	Median   Missing = "median"   // Replaced by the median (most frequent category if categorical)
+	Constant Missing = "constant" // Replaced by the constant (no category if categorical)
+)
+
+// Column describes how a column is converted
+type Column struct {
+	Name        string  `json:"name"`
+	Categorical bool    `json:"categorical,omitempty"` // One-hot encoded if set
+	Indexed     bool    `json:"indexed,omitempty"`     // Categorical encoded as the index of its category (-1 if unknown), see mlp.Embedding
+	Missing     Missing `json:"missing,omitempty"`
+	Constant    float64 `json:"constant,omitempty"` // Value used by the constant strategy
+
+	// Fitted parameters
+	Categories []string `json:"categories,omitempty"` // Known categories, sorted
+	Fill       string   `json:"fill,omitempty"`       // Value replacing missing cells
+}
+
+// Pipeline converts a table to network inputs (features) and outputs (targets)
+// Once fitted, it can be saved as json and applied at predict time
+type Pipeline struct {
+	Features []Column `json:"features"`
+	Targets  []Column `json:"targets"`
+	Fitted   bool     `json:"fitted"`
+}
+
+// NewPipeline builds a pipeline of numeric columns, missing values are errors
+func NewPipeline(features, targets []string) Pipeline {
+	columns := func(names []string) []Column {
+		cols := make([]Column, len(names))
+		for i, name := range names {
+			cols[i] = Column{Name: name}
+		}
+		return cols
+	}
+	return Pipeline{
+		Features: columns(features),
+		Targets:  columns(targets),
+	}
+}
+
+// Fit computes the categories and the replacement values of each column
+func (p *Pipeline) Fit(t Table) error {
+	columns := append(append([]Column{}, p.Features...), p.Targets...)
+	indexes, err := indexesOf(t, columns)
+	if err != nil {
+		return err
+	}
+	rows := keptRows(t, columns, indexes)
+	if len(rows) == 0 {
+		return fmt.Errorf("no row to fit")
+	}
+
+	for i := range columns {
+		cells := make([]string, 0, len(rows))
+		for _, row := range rows {
+			if cell := row[indexes[i]]; !isMissing(cell) {
+				cells = append(cells, strings.TrimSpace(cell))
+			}
+		}
+		if err := columns[i].fit(cells, len(cells) < len(rows)); err != nil {
+			return err
+		}
+	}
+
+	p.Features = columns[:len(p.Features)]
+	p.Targets = columns[len(p.Features):]
+	p.Fitted = true
+	return nil
+}
+
+// Transform converts a table to inputs and outputs
+func (p Pipeline) Transform(t Table) ([][]float64, [][]float64, error) {
+	columns := append(append([]Column{}, p.Features...), p.Targets...)
+	values, err := p.transform(t, columns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	width := encodedWidth(p.Features)
+	xData := make([][]float64, len(values))
+	yData := make([][]float64, len(values))
+	for i, row := range values {
+		xData[i] = row[:width:width]
+		yData[i] = row[width:]
+	}
+	return xData, yData, nil
+}
+
+// TransformFeatures converts a table to inputs only, target columns are not required
+func (p Pipeline) TransformFeatures(t Table) ([][]float64, error) {
+	return p.transform(t, p.Features)
+}
+
+// FitTransform fits the pipeline then converts the table
+func (p *Pipeline) FitTransform(t Table) ([][]float64, [][]float64, error) {
+	if err := p.Fit(t); err != nil {
+		return nil, nil, err
+	}
+	return p.Transform(t)
+}
+
+// FeatureNames returns the name of each input value
+func (p Pipeline) FeatureNames() []string {
+	return encodedNames(p.Features)
+}
+
+// TargetNames returns the name of each output value
+func (p Pipeline) TargetNames() []string {
+	return encodedNames(p.Targets)
+}
+
+// transform converts the given columns of each kept row
+func (p Pipeline) transform(t Table, columns []Column) ([][]float64, error) {
+	if !p.Fitted {
+		return nil, fmt.Errorf("pipeline shall be fitted")
+	}
+	indexes, err := indexesOf(t, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]float64, 0, len(t.Rows))
+	for i, row := range t.Rows {
+		encoded, kept, err := encodeRow(row, columns, indexes)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		if kept {
+			values = append(values, encoded)
+		}
+	}
+	return values, nil
+}
+
+// encodeRow converts the given columns of a row, unless a "drop" column is missing
+func encodeRow(row []string, columns []Column, indexes []int) ([]float64, bool, error) {
+	if !isKept(row, columns, indexes) {
+		return nil, false, nil
+	}
+
+	values := make([]float64, 0, encodedWidth(columns))
+	for j, col := range columns {
+		encoded, err := col.encode(row[indexes[j]])
+		if err != nil {
+			return nil, false, err
+		}
+		values = append(values, encoded...)
+	}
+	return values, true, nil
+}
+
+// fit computes the column parameters from its non missing cells
+func (c *Column) fit(cells []string, missing bool) error {
+	if missing && c.Missing == Fail {
+		return fmt.Errorf("column %q: missing values found", c.Name)
+	}
+
+	if c.Categorical {
+		counts := map[string]int{}
+		for _, cell := range cells {
+			counts[cell]++
+		}
+		c.Categories = make([]string, 0, len(counts))
+		for category := range counts {
+			c.Categories = append(c.Categories, category)
+		}
+		sort.Strings(c.Categories)
+
+		c.Fill = ""
+		if c.Missing == Mean || c.Missing == Median {
+			for _, category := range c.Categories {
+				if counts[category] > counts[c.Fill] {
+					c.Fill = category // most frequent, first in order if equal
+				}
+			}
+		}
+		return nil
+	}
+
+	values := make([]float64, len(cells))
+	for i, cell := range cells {
+		value, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", c.Name, err)
+		}
+		values[i] = value
+	}
+
+	if len(values) == 0 && (c.Missing == Mean || c.Missing == Median) {
+		return fmt.Errorf("column %q: no value to compute the %s", c.Name, c.Missing)
+	}
+
+	var fill float64
+	switch c.Missing {
+	case Mean:
+		for _, value := range values {
+			fill += value
+		}
+		fill /= float64(len(values))
+	case Median:
+		sort.Float64s(values)
+		if n := len(values); n%2 == 1 {
+			fill = values[n/2]
+		} else {
+			fill = (values[n/2-1] + values[n/2]) / 2
+		}
+	case Constant:
+		fill = c.Constant
+	}
+	c.Fill = strconv.FormatFloat(fill, 'g', -1, 64)
+	return nil
+}
+
+// encode converts a cell, one-hot vector (or index) if categorical
+// Unknown categories are encoded as zeros (or -1)
+func (c Column) encode(cell string) ([]float64, error) {
+	cell = strings.TrimSpace(cell)
+	if isMissing(cell) {
+		if c.Missing == Fail {
+			return nil, fmt.Errorf("column %q: missing value", c.Name)
+		}
+		cell = c.Fill
+	}
+
+	if c.Categorical {
+		i := sort.SearchStrings(c.Categories, cell)
+		known := i < len(c.Categories) && c.Categories[i] == cell
+		if c.Indexed {
+			if !known {
+				i = -1
+			}
+			return []float64{float64(i)}, nil
+		}
+		encoded := make([]float64, len(c.Categories))
+		if known {
+			encoded[i] = 1
+		}
+		return encoded, nil
+	}
+
+	value, err := strconv.ParseFloat(cell, 64)
+	if err != nil {
+		return nil, fmt.Errorf("column %q: %w", c.Name, err)
+	}
+	return []float64{value}, nil
+}
+
+// indexesOf returns the table index of each column
+func indexesOf(t Table, columns []Column) ([]int, error) {
+	indexes := make([]int, len(columns))
+	for i, col := range columns {
+		index, err := t.Column(col.Name)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i] = index
+	}
+	return indexes, nil
+}
+
+// keptRows removes rows with a missing value in a "drop" column
+func keptRows(t Table, columns []Column, indexes []int) [][]string {
+	rows := make([][]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		if isKept(row, columns, indexes) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// isKept checks that no "drop" column is missing
+func isKept(row []string, columns []Column, indexes []int) bool {
+	for i, col := range columns {
+		if col.Missing == Drop && isMissing(row[indexes[i]]) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodedWidth computes the number of values produced by the columns
+func encodedWidth(columns []Column) int {
+	var width int
+	for _, col := range columns {
+		if col.Categorical && !col.Indexed {
+			width += len(col.Categories)
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// encodedNames returns the name of each value produced by the columns ("column=category" if one-hot encoded)
+func encodedNames(columns []Column) []string {
+	var names []string
+	for _, col := range columns {
+		if !col.Categorical || col.Indexed {
+			names = append(names, col.Name)
+			continue
+		}
+		for _, category := range col.Categories {
+			names = append(names, col.Name+"="+category)
+		}
+	}
+	return names
+}
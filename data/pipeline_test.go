@@ -0,0 +1,112 @@
+package data
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPipeline(t *testing.T) {
+	Convey("pipeline", t, func() {
+		table, err := ReadCSV(strings.NewReader(
+			"age\tcountry\tsize\tlabel\n"+
+				"10\tfr\t1.5\tyes\n"+
+				"NA\tus\t2.5\tno\n"+
+				"30\t\t\tyes\n"+
+				"40\tfr\t4\t?\n",
+		), '\t')
+		So(err, ShouldBeNil)
+		So(table.Header, ShouldResemble, []string{"age", "country", "size", "label"})
+		So(table.Rows, ShouldHaveLength, 4)
+
+		Convey("fit and transform", func() {
+			p := Pipeline{
+				Features: []Column{
+					{Name: "age", Missing: Median},
+					{Name: "country", Categorical: true, Missing: Mean},
+					{Name: "size", Missing: Constant, Constant: -1},
+				},
+				Targets: []Column{
+					{Name: "label", Categorical: true, Missing: Drop},
+				},
+			}
+			xData, yData, err := p.FitTransform(table)
+			So(err, ShouldBeNil)
+			So(p.Features[0].Fill, ShouldEqual, "20")
+			So(p.Features[1].Categories, ShouldResemble, []string{"fr", "us"})
+			So(p.Features[1].Fill, ShouldEqual, "fr")
+			So(p.Targets[0].Categories, ShouldResemble, []string{"no", "yes"})
+			So(p.FeatureNames(), ShouldResemble, []string{"age", "country=fr", "country=us", "size"})
+			So(p.TargetNames(), ShouldResemble, []string{"label=no", "label=yes"})
+
+			So(xData, ShouldResemble, [][]float64{
+				{10, 1, 0, 1.5},
+				{20, 0, 1, 2.5},
+				{30, 1, 0, -1},
+			})
+			So(yData, ShouldResemble, [][]float64{{0, 1}, {1, 0}, {0, 1}})
+
+			Convey("serialize and apply at predict time", func() {
+				js, err := json.Marshal(p)
+				So(err, ShouldBeNil)
+				var p2 Pipeline
+				So(json.Unmarshal(js, &p2), ShouldBeNil)
+				So(p2, ShouldResemble, p)
+
+				features, err := ReadCSV(strings.NewReader("size,country,age\n3,de,\n"), ',')
+				So(err, ShouldBeNil)
+				xData, err := p2.TransformFeatures(features)
+				So(err, ShouldBeNil)
+				So(xData, ShouldResemble, [][]float64{{20, 0, 0, 3}}) // unknown country
+			})
+		})
+
+		Convey("indexed categories", func() {
+			p := Pipeline{Features: []Column{
+				{Name: "country", Categorical: true, Indexed: true, Missing: Constant},
+				{Name: "age", Missing: Median},
+			}}
+			So(p.Fit(table), ShouldBeNil)
+			So(p.FeatureNames(), ShouldResemble, []string{"country", "age"})
+			xData, err := p.TransformFeatures(table)
+			So(err, ShouldBeNil)
+			So(xData, ShouldResemble, [][]float64{{0, 10}, {1, 30}, {-1, 30}, {0, 40}}) // missing country
+		})
+
+		Convey("mean", func() {
+			p := Pipeline{Features: []Column{{Name: "age", Missing: Mean}}}
+			xData, err := func() ([][]float64, error) {
+				if err := p.Fit(table); err != nil {
+					return nil, err
+				}
+				return p.TransformFeatures(table)
+			}()
+			So(err, ShouldBeNil)
+			So(xData, ShouldResemble, [][]float64{{10}, {80.0 / 3}, {30}, {40}})
+		})
+
+		Convey("errors", func() {
+			p := NewPipeline([]string{"age"}, []string{"size"})
+			So(p.Fit(table), ShouldBeError, `column "age": missing values found`)
+
+			_, err := p.TransformFeatures(table)
+			So(err, ShouldBeError, "pipeline shall be fitted")
+
+			p = NewPipeline([]string{"unknown"}, nil)
+			So(p.Fit(table), ShouldBeError, `unknown column "unknown"`)
+
+			p = NewPipeline([]string{"country"}, nil)
+			p.Features[0].Missing = Drop
+			So(p.Fit(table), ShouldNotBeNil) // not numeric
+
+			empty, err := ReadCSV(strings.NewReader("age\nNA\n?\n"), '\t')
+			So(err, ShouldBeNil)
+			for _, missing := range []Missing{Mean, Median} {
+				p = Pipeline{Features: []Column{{Name: "age", Missing: missing}}}
+				So(p.Fit(empty), ShouldBeError, `column "age": no value to compute the `+string(missing))
+			}
+		})
+	})
+}
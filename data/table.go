@@ -0,0 +1,72 @@
+// Package data reads tabular data and converts them to network inputs and outputs
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Table of string cells with a header
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// ReadCSV reads a csv content with a header using the given separator
+func ReadCSV(r io.Reader, comma rune) (Table, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return Table{}, err
+	}
+	if len(rows) == 0 {
+		return Table{}, fmt.Errorf("header expected")
+	}
+	return Table{
+		Header: rows[0],
+		Rows:   rows[1:],
+	}, nil
+}
+
+// ReadFile reads a csv file, or a tsv file if the extension is ".tsv"
+func ReadFile(path string) (Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Table{}, err
+	}
+	defer file.Close()
+	return ReadCSV(file, Separator(path))
+}
+
+// Separator guesses the separator using the file extension
+func Separator(path string) rune {
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		return '\t'
+	}
+	return ','
+}
+
+// Column returns the index of a column
+func (t Table) Column(name string) (int, error) {
+	for i, col := range t.Header {
+		if col == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown column %q", name)
+}
+
+// isMissing checks if a cell has no value
+func isMissing(cell string) bool {
+	switch strings.ToLower(strings.TrimSpace(cell)) {
+	case "", "na", "n/a", "nan", "null", "?":
+		return true
+	default:
+		return false
+	}
+}
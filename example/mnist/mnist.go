@@ -1,8 +1,10 @@
 package mnist
 
 import (
-	"encoding/binary"
-	"os"
+	"fmt"
+	"io"
+
+	"github.com/sbiemont/simlpe/idx"
 )
 
 const (
@@ -19,7 +21,7 @@ type database struct {
 	labels []byte
 }
 
-// init database using labels + images path files
+// init database using labels + images path files (optionally gzip compressed)
 func newDatabase(pathLabels, pathImages string) (*database, error) {
 	labels, err := readLabels(pathLabels)
 	if err != nil {
@@ -29,6 +31,9 @@ func newDatabase(pathLabels, pathImages string) (*database, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(labels) != len(images) {
+		return nil, fmt.Errorf("%d labels and %d images found", len(labels), len(images))
+	}
 	return &database{
 		w:      w,
 		h:      h,
@@ -37,32 +42,50 @@ func newDatabase(pathLabels, pathImages string) (*database, error) {
 	}, nil
 }
 
+// readRecords reads all unsigned bytes records of an idx file with the given number of dimensions
+func readRecords(path string, dims int) ([][]byte, idx.Header, error) {
+	r, err := idx.Open(path)
+	if err != nil {
+		return nil, idx.Header{}, err
+	}
+	defer r.Close()
+
+	header := r.Header()
+	if header.Type != idx.UByte || len(header.Dims) != dims {
+		return nil, idx.Header{}, fmt.Errorf("%s: %d dimensions of %s expected", path, dims, idx.UByte)
+	}
+
+	var records [][]byte
+	for {
+		raw, err := r.NextRaw()
+		if err == io.EOF {
+			return records, header, nil
+		}
+		if err != nil {
+			return nil, idx.Header{}, err
+		}
+		records = append(records, append([]byte(nil), raw...))
+	}
+}
+
 // read labels from mnist file
 func readLabels(path string) ([]byte, error) {
-	file, err := os.Open(path)
+	records, _, err := readRecords(path, 1)
 	if err != nil {
 		return nil, err
 	}
-	header := [2]int32{}
-	binary.Read(file, binary.BigEndian, &header)
-	labels := make([]byte, header[1])
-	_, err = file.Read(labels)
-	return labels, err
+	labels := make([]byte, len(records))
+	for i, record := range records {
+		labels[i] = record[0]
+	}
+	return labels, nil
 }
 
 // read images from mnist file
 func readImages(path string) ([][]byte, int, int, error) {
-	file, err := os.Open(path)
+	images, header, err := readRecords(path, 3)
 	if err != nil {
 		return nil, 0, 0, err
 	}
-	header := [4]int32{}
-	binary.Read(file, binary.BigEndian, &header)
-	images := make([][]byte, header[1])
-	width, height := int(header[2]), int(header[3])
-	for i := 0; i < len(images); i++ {
-		images[i] = make([]byte, width*height)
-		file.Read(images[i])
-	}
-	return images, width, height, nil
+	return images, header.Dims[2], header.Dims[1], nil
 }
@@ -80,6 +80,58 @@ func TestTrain(t *testing.T) {
 		_, errWrite := f.Write(str)
 		So(errWrite, ShouldBeNil)
 	})
+
+	Convey("cnn", t, func() {
+		rand.Seed(42)
+
+		// Read train database
+		db, err := newDatabase(TrainLabelsFile, TrainImagesFile)
+		So(err, ShouldBeNil)
+		inputs := imageToInput(db.images)
+		outputs := labelsToOutput(db.labels)
+
+		// conv 3x3 (8 filters) + max pool 2x2 + linear
+		net := mlp.NewNetwork(0.05, db.h*db.w)
+		conv, err := mlp.NewConv2D(mlp.Shape{Channels: 1, Height: db.h, Width: db.w}, 8, 3, 1, 1)
+		So(err, ShouldBeNil)
+		pool, err := mlp.NewMaxPool2D(conv.OutputShape(), 2, 2)
+		So(err, ShouldBeNil)
+		flatten, err := mlp.NewFlatten(pool.OutputShape())
+		So(err, ShouldBeNil)
+		for _, layer := range []mlp.Layer{conv, mlp.NewActivatorLayer(mlp.ReLU{}), pool, flatten} {
+			So(net.Add(layer), ShouldBeNil)
+		}
+		net.AddLayer(mlp.LinearBuilder{Initializer: mlp.Xavier{}}, 10, mlp.Sigmoid{})
+		net.Stop.OnEpoch(2)
+
+		stop, err := net.Train(ctx, inputs, outputs)
+		So(err, ShouldBeNil)
+		fmt.Println(stop)
+
+		str, err := net.MarshalJSON()
+		So(err, ShouldBeNil)
+		So(os.WriteFile("cnn.json", str, 0o644), ShouldBeNil)
+	})
+}
+
+// check counts the well classified images of the check database
+func check(path string, inputs [][]float64, labels []byte) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	net := mlp.Network{}
+	if err := net.UnmarshalJSON(content); err != nil {
+		return 0, err
+	}
+
+	var ok int
+	for i, input := range inputs {
+		if outputToLabel(net.Predict(input)) == labels[i] {
+			ok++
+		}
+	}
+	return ok, nil
 }
 
 func TestCheck(t *testing.T) {
@@ -116,5 +168,11 @@ func TestCheck(t *testing.T) {
 		So(len(dbCheck.labels), ShouldEqual, 10000)
 		So(ok, ShouldBeGreaterThan, 9300) // > 93%
 		fmt.Println(ok)
+
+		// A small convolutional network does better
+		okCNN, err := check("cnn.json", inputsCheck, dbCheck.labels)
+		So(err, ShouldBeNil)
+		So(okCNN, ShouldBeGreaterThan, ok)
+		fmt.Println(okCNN)
 	})
 }
@@ -1,6 +1,6 @@
 module github.com/sbiemont/simlpe
 
-go 1.19
+go 1.21
 
 require github.com/smartystreets/goconvey v1.7.2
 
@@ -0,0 +1,92 @@
+package idx
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Dataset streams an idx images file and its labels file as network inputs and one-hot outputs,
+// both files are read again at each pass
+type Dataset struct {
+	Images  string  // Path of the inputs file
+	Labels  string  // Path of the labels file (one value per record)
+	Classes int     // Number of outputs
+	Scale   float64 // Inputs are divided by the scale (if not null)
+}
+
+// Open opens both files and checks their headers
+func (ds Dataset) Open() (mlp.Iterator, error) {
+	images, err := Open(ds.Images)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := Open(ds.Labels)
+	if err != nil {
+		images.Close()
+		return nil, err
+	}
+
+	it := &datasetIterator{
+		images:  images,
+		labels:  labels,
+		classes: ds.Classes,
+		scale:   ds.Scale,
+	}
+	switch {
+	case labels.Header().RecordSize() != 1:
+		err = fmt.Errorf("%s: one label per record expected", ds.Labels)
+	case images.Header().Records() != labels.Header().Records():
+		err = fmt.Errorf("%d images and %d labels found", images.Header().Records(), labels.Header().Records())
+	case ds.Classes <= 0:
+		err = fmt.Errorf("at least one class expected")
+	}
+	if err != nil {
+		it.Close()
+		return nil, err
+	}
+	return it, nil
+}
+
+// datasetIterator reads images and labels records
+type datasetIterator struct {
+	images, labels *Reader
+	classes        int
+	scale          float64
+}
+
+func (it *datasetIterator) Next() ([]float64, []float64, error) {
+	x, err := it.images.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+	label, err := it.labels.Next()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	class := int(label[0])
+	if class < 0 || class >= it.classes {
+		return nil, nil, fmt.Errorf("label %d out of [0, %d[", class, it.classes)
+	}
+	if it.scale != 0 {
+		for j := range x {
+			x[j] /= it.scale
+		}
+	}
+	y := make([]float64, it.classes)
+	y[class] = 1
+	return x, y, nil
+}
+
+func (it *datasetIterator) Close() error {
+	err := it.images.Close()
+	if errClose := it.labels.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
@@ -0,0 +1,275 @@
+// Package idx reads files using the idx format of the mnist database
+// (also used by fashion-mnist, emnist, kmnist...), optionally gzip compressed
+//
+// The header is made of:
+//   - 2 zero bytes
+//   - the type of the values (1 byte)
+//   - the number of dimensions (1 byte)
+//   - the size of each dimension (big endian int32), the first one being the number of records
+//
+// Values are then stored in big endian order
+package idx
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Type of the stored values
+type Type byte
+
+const (
+	UByte  Type = 0x08 // unsigned byte
+	Byte   Type = 0x09 // signed byte
+	Short  Type = 0x0B // 2 bytes
+	Int    Type = 0x0C // 4 bytes
+	Float  Type = 0x0D // 4 bytes
+	Double Type = 0x0E // 8 bytes
+)
+
+// Size returns the number of bytes of a value (0 if the type is unknown)
+func (t Type) Size() int {
+	switch t {
+	case UByte, Byte:
+		return 1
+	case Short:
+		return 2
+	case Int, Float:
+		return 4
+	case Double:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// String converts to constants
+func (t Type) String() string {
+	switch t {
+	case UByte:
+		return "ubyte"
+	case Byte:
+		return "byte"
+	case Short:
+		return "short"
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Double:
+		return "double"
+	default:
+		return fmt.Sprintf("unknown(0x%02x)", byte(t))
+	}
+}
+
+// maxRecordBytes bounds the size of a record read from an untrusted header
+const maxRecordBytes = 1 << 30
+
+// Header of an idx file
+type Header struct {
+	Type Type
+	Dims []int // Size of each dimension, the first one being the number of records
+}
+
+// Records returns the number of records
+func (h Header) Records() int {
+	return h.Dims[0]
+}
+
+// RecordSize returns the number of values of a record
+func (h Header) RecordSize() int {
+	size := 1
+	for _, dim := range h.Dims[1:] {
+		size *= dim
+	}
+	return size
+}
+
+// Reader streams the records of an idx content
+type Reader struct {
+	header  Header
+	reader  *bufio.Reader
+	closers []io.Closer
+	read    int    // Number of records read
+	buf     []byte // Raw record
+}
+
+// NewReader reads and validates the header, gzip compressed contents are detected
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := bufio.NewReader(r)
+	var closers []io.Closer
+
+	// Gzip magic number
+	magic, err := reader.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %w", err)
+	}
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, gz)
+		reader = bufio.NewReader(gz)
+	}
+
+	header, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		header:  header,
+		reader:  reader,
+		closers: closers,
+		buf:     make([]byte, header.RecordSize()*header.Type.Size()),
+	}, nil
+}
+
+// Open opens an idx file (gzip compressed or not)
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.closers = append(r.closers, file)
+	return r, nil
+}
+
+// readHeader reads and checks the magic number and the dimensions
+func readHeader(r io.Reader) (Header, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return Header{}, fmt.Errorf("cannot read header: %w", err)
+	}
+	if magic[0] != 0 || magic[1] != 0 {
+		return Header{}, fmt.Errorf("invalid magic number 0x%x", magic)
+	}
+	typ := Type(magic[2])
+	if typ.Size() == 0 {
+		return Header{}, fmt.Errorf("unknown type 0x%02x", magic[2])
+	}
+	if magic[3] == 0 {
+		return Header{}, fmt.Errorf("at least one dimension expected")
+	}
+
+	dims := make([]int32, magic[3])
+	if err := binary.Read(r, binary.BigEndian, dims); err != nil {
+		return Header{}, fmt.Errorf("cannot read dimensions: %w", err)
+	}
+	header := Header{
+		Type: typ,
+		Dims: make([]int, len(dims)),
+	}
+	recordBytes := typ.Size()
+	for i, dim := range dims {
+		if dim <= 0 {
+			return Header{}, fmt.Errorf("invalid dimension %d", dim)
+		}
+		header.Dims[i] = int(dim)
+		if i > 0 {
+			if recordBytes > maxRecordBytes/int(dim) {
+				return Header{}, fmt.Errorf("record size exceeds %d bytes", maxRecordBytes)
+			}
+			recordBytes *= int(dim)
+		}
+	}
+	return header, nil
+}
+
+// Header returns the header of the content
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// NextRaw returns the next record as big endian raw bytes, or io.EOF when all records are read.
+// The returned slice is reused by the next call.
+func (r *Reader) NextRaw() ([]byte, error) {
+	if r.read >= r.header.Records() {
+		return nil, io.EOF
+	}
+	if _, err := io.ReadFull(r.reader, r.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("record %d: %w", r.read, err)
+	}
+	r.read++
+	return r.buf, nil
+}
+
+// Next returns the values of the next record, or io.EOF when all records are read
+func (r *Reader) Next() ([]float64, error) {
+	raw, err := r.NextRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	size := r.header.Type.Size()
+	values := make([]float64, len(raw)/size)
+	for i := range values {
+		values[i] = decode(r.header.Type, raw[i*size:(i+1)*size])
+	}
+	return values, nil
+}
+
+// Close releases the gzip reader and the file, if any
+func (r *Reader) Close() error {
+	var err error
+	for _, closer := range r.closers {
+		if errClose := closer.Close(); err == nil {
+			err = errClose
+		}
+	}
+	return err
+}
+
+// ReadAll reads the header and all records of an idx file
+func ReadAll(path string) (Header, [][]float64, error) {
+	r, err := Open(path)
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer r.Close()
+
+	var records [][]float64
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return r.header, records, nil
+		}
+		if err != nil {
+			return Header{}, nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// decode converts one big endian value
+func decode(typ Type, raw []byte) float64 {
+	switch typ {
+	case UByte:
+		return float64(raw[0])
+	case Byte:
+		return float64(int8(raw[0]))
+	case Short:
+		return float64(int16(binary.BigEndian.Uint16(raw)))
+	case Int:
+		return float64(int32(binary.BigEndian.Uint32(raw)))
+	case Float:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw)))
+	default: // Double
+		return math.Float64frombits(binary.BigEndian.Uint64(raw))
+	}
+}
@@ -0,0 +1,180 @@
+package idx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// content builds an idx content
+func content(typ Type, dims []int32, values interface{}) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, byte(typ), byte(len(dims))})
+	binary.Write(&buf, binary.BigEndian, dims)
+	binary.Write(&buf, binary.BigEndian, values)
+	return buf.Bytes()
+}
+
+// readAll reads all records of a content
+func readAll(data []byte) (Header, [][]float64, error) {
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer r.Close()
+
+	var records [][]float64
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return r.Header(), records, nil
+		}
+		if err != nil {
+			return Header{}, nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+func TestReader(t *testing.T) {
+	Convey("idx", t, func() {
+		Convey("types", func() {
+			tests := []struct {
+				typ    Type
+				values interface{}
+			}{
+				{UByte, []uint8{1, 2, 255, 4}},
+				{Byte, []int8{1, 2, -1, 4}},
+				{Short, []int16{1, 2, -1, 4}},
+				{Int, []int32{1, 2, -1, 4}},
+				{Float, []float32{1, 2, -1, 4}},
+				{Double, []float64{1, 2, -1, 4}},
+			}
+			for _, test := range tests {
+				header, records, err := readAll(content(test.typ, []int32{2, 2}, test.values))
+				So(err, ShouldBeNil)
+				So(header, ShouldResemble, Header{Type: test.typ, Dims: []int{2, 2}})
+				third := -1.0
+				if test.typ == UByte {
+					third = 255
+				}
+				So(records, ShouldResemble, [][]float64{{1, 2}, {third, 4}})
+			}
+		})
+
+		Convey("dimensions", func() {
+			values := make([]uint8, 2*3*4*5)
+			for i := range values {
+				values[i] = uint8(i)
+			}
+			header, records, err := readAll(content(UByte, []int32{2, 3, 4, 5}, values))
+			So(err, ShouldBeNil)
+			So(header.Records(), ShouldEqual, 2)
+			So(header.RecordSize(), ShouldEqual, 60)
+			So(records, ShouldHaveLength, 2)
+			So(records[1][59], ShouldEqual, 119)
+
+			// Labels: 1 dimension
+			header, records, err = readAll(content(UByte, []int32{3}, []uint8{7, 8, 9}))
+			So(err, ShouldBeNil)
+			So(header.RecordSize(), ShouldEqual, 1)
+			So(records, ShouldResemble, [][]float64{{7}, {8}, {9}})
+		})
+
+		Convey("gzip file", func() {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write(content(Short, []int32{1, 3}, []int16{-3, 0, 3}))
+			gz.Close()
+
+			path := filepath.Join(t.TempDir(), "data.idx2-short.gz")
+			So(os.WriteFile(path, buf.Bytes(), 0o644), ShouldBeNil)
+			header, records, err := ReadAll(path)
+			So(err, ShouldBeNil)
+			So(header.Type, ShouldEqual, Short)
+			So(records, ShouldResemble, [][]float64{{-3, 0, 3}})
+		})
+
+		Convey("errors", func() {
+			_, err := NewReader(bytes.NewReader([]byte{1, 0, 8, 1, 0, 0, 0, 0}))
+			So(err, ShouldBeError, "invalid magic number 0x01000801")
+
+			_, err = NewReader(bytes.NewReader([]byte{0, 0, 0x0a, 1, 0, 0, 0, 0}))
+			So(err, ShouldBeError, "unknown type 0x0a")
+
+			_, err = NewReader(bytes.NewReader([]byte{0, 0, 8, 0}))
+			So(err, ShouldBeError, "at least one dimension expected")
+
+			_, err = NewReader(bytes.NewReader([]byte{0, 0, 8, 2, 0, 0, 0, 1}))
+			So(err, ShouldNotBeNil) // missing dimension
+
+			_, err = NewReader(bytes.NewReader(content(UByte, []int32{1, 0}, []uint8{})))
+			So(err, ShouldBeError, "invalid dimension 0")
+			_, err = NewReader(bytes.NewReader(content(UByte, []int32{1, -2}, []uint8{})))
+			So(err, ShouldBeError, "invalid dimension -2")
+			_, err = NewReader(bytes.NewReader(content(Double, []int32{1, 1 << 30, 1 << 30, 1 << 30}, []uint8{})))
+			So(err, ShouldBeError, "record size exceeds 1073741824 bytes")
+
+			_, _, err = readAll(content(UByte, []int32{3, 2}, []uint8{1, 2, 3, 4, 5}))
+			So(err, ShouldBeError, "record 2: unexpected EOF")
+
+			_, _, err = ReadAll(filepath.Join(t.TempDir(), "missing"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestDataset(t *testing.T) {
+	Convey("idx dataset", t, func() {
+		dir := t.TempDir()
+		images := filepath.Join(dir, "images.idx3-ubyte")
+		labels := filepath.Join(dir, "labels.idx1-ubyte")
+		So(os.WriteFile(images, content(UByte, []int32{2, 1, 2}, []uint8{0, 255, 51, 102}), 0o644), ShouldBeNil)
+		So(os.WriteFile(labels, content(UByte, []int32{2}, []uint8{2, 0}), 0o644), ShouldBeNil)
+
+		Convey("read", func() {
+			it, err := Dataset{Images: images, Labels: labels, Classes: 3, Scale: 255}.Open()
+			So(err, ShouldBeNil)
+			defer it.Close()
+
+			x, y, err := it.Next()
+			So(err, ShouldBeNil)
+			So(x, ShouldResemble, []float64{0, 1})
+			So(y, ShouldResemble, []float64{0, 0, 1})
+			x, y, err = it.Next()
+			So(err, ShouldBeNil)
+			So(x, ShouldResemble, []float64{0.2, 0.4})
+			So(y, ShouldResemble, []float64{1, 0, 0})
+			_, _, err = it.Next()
+			So(err, ShouldEqual, io.EOF)
+		})
+
+		Convey("errors", func() {
+			_, err := Dataset{Images: images, Labels: images, Classes: 3}.Open()
+			So(err, ShouldBeError, images+": one label per record expected")
+
+			short := filepath.Join(dir, "short.idx1-ubyte")
+			So(os.WriteFile(short, content(UByte, []int32{1}, []uint8{0}), 0o644), ShouldBeNil)
+			_, err = Dataset{Images: images, Labels: short, Classes: 3}.Open()
+			So(err, ShouldBeError, "2 images and 1 labels found")
+
+			_, err = Dataset{Images: images, Labels: labels}.Open()
+			So(err, ShouldBeError, "at least one class expected")
+
+			_, err = Dataset{Images: images, Labels: filepath.Join(dir, "missing"), Classes: 3}.Open()
+			So(err, ShouldNotBeNil)
+
+			it, err := Dataset{Images: images, Labels: labels, Classes: 2}.Open()
+			So(err, ShouldBeNil)
+			_, _, err = it.Next()
+			So(err, ShouldBeError, "label 2 out of [0, 2[")
+			So(it.Close(), ShouldBeNil)
+		})
+	})
+}
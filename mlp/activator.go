@@ -1,6 +1,7 @@
 package mlp
 
 import (
+	"fmt"
 	"math"
 )
 
@@ -70,3 +71,16 @@ func (r ReLU) String() string {
 	return "relu"
 }
 
+// ParseActivator converts a constant to an activator
+func ParseActivator(name string) (Activator, error) {
+	switch name {
+	case "sigmoid":
+		return Sigmoid{}, nil
+	case "htan":
+		return Htan{}, nil
+	case "relu":
+		return ReLU{}, nil
+	default:
+		return nil, fmt.Errorf("unknown activator %q", name)
+	}
+}
@@ -2,24 +2,23 @@ package mlp
 
 import (
 	"encoding/json"
-	"fmt"
 )
 
-// activatorLayer is a layer-like build from an activator
-type activatorLayer struct {
+// ActivatorLayer is a layer-like build from an activator
+type ActivatorLayer struct {
 	act Activator
 }
 
-// newActivatorLayer builds a new layer from an activator
-func newActivatorLayer(act Activator) activatorLayer {
-	return activatorLayer{
+// NewActivatorLayer builds a new layer from an activator
+func NewActivatorLayer(act Activator) ActivatorLayer {
+	return ActivatorLayer{
 		act: act,
 	}
 }
 
 // FeedForward activates all input values one by one
 // yi = activ(xi)
-func (al activatorLayer) FeedForward(x []float64) []float64 {
+func (al ActivatorLayer) FeedForward(x []float64) []float64 {
 	y := newVector(len(x))
 	return y.iter(func(i int) {
 		y[i] = al.act.Activ(x[i])
@@ -28,7 +27,7 @@ func (al activatorLayer) FeedForward(x []float64) []float64 {
 
 // BackPropagation derivates all values one by one and multiplies par the y gradient
 // yi = yGrad_i * deriv(xi)
-func (al activatorLayer) BackPropagation(x, yGrad []float64) []float64 {
+func (al ActivatorLayer) BackPropagation(x, yGrad []float64) []float64 {
 	xGrad := newVector(len(x))
 	return xGrad.iter(func(i int) {
 		xGrad[i] = yGrad[i] * al.act.Deriv(x[i])
@@ -36,26 +35,31 @@ func (al activatorLayer) BackPropagation(x, yGrad []float64) []float64 {
 }
 
 // Update does nothing
-func (al activatorLayer) Update(learninRate float64) {
+func (al ActivatorLayer) Update(learninRate float64) {
 	// No processing
 }
 
-func (al activatorLayer) Type() string {
+func (al ActivatorLayer) Type() string {
 	return "activator"
 }
 
+// Activator returns the activation function of the layer
+func (al ActivatorLayer) Activator() Activator {
+	return al.act
+}
+
 // for marshal/unmarshal an activation layer
 type exportActivationLayer struct {
 	Fct string `json:"fct"`
 }
 
-func (al activatorLayer) MarshalJSON() ([]byte, error) {
+func (al ActivatorLayer) MarshalJSON() ([]byte, error) {
 	return json.Marshal(exportActivationLayer{
 		Fct: al.act.String(),
 	})
 }
 
-func (al *activatorLayer) UnmarshalJSON(data []byte) error {
+func (al *ActivatorLayer) UnmarshalJSON(data []byte) error {
 	var exp exportActivationLayer
 	err := json.Unmarshal(data, &exp)
 	if err != nil {
@@ -63,19 +67,12 @@ func (al *activatorLayer) UnmarshalJSON(data []byte) error {
 	}
 
 	// Convert activator
-	var act Activator
-	switch exp.Fct {
-	case "sigmoid":
-		act = Sigmoid{}
-	case "htan":
-		act = Htan{}
-	case "relu":
-		act = ReLU{}
-	default:
-		return fmt.Errorf("unknown activator %q", exp.Fct)
+	act, err := ParseActivator(exp.Fct)
+	if err != nil {
+		return err
 	}
 
 	// Fill layer
-	*al = newActivatorLayer(act)
+	*al = NewActivatorLayer(act)
 	return nil
 }
@@ -4,8 +4,13 @@ type LayerBuilder interface {
 	New(in, out int) Layer
 }
 
-type LinearBuilder struct{}
+// LinearBuilder builds linear layers
+// By default, weights are initialized with the standard normal distribution and are not regularized
+type LinearBuilder struct {
+	Initializer    Initializer    // Optional weights initialization
+	Regularization Regularization // Optional weights penalty
+}
 
 func (bld LinearBuilder) New(in, out int) Layer {
-	return NewLinear(in, out)
+	return newLinear(in, out, bld.Initializer, bld.Regularization)
 }
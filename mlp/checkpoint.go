@@ -0,0 +1,184 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// checkpointPattern is the name of the checkpoint files written in a directory
+const checkpointPattern = "checkpoint-%06d.json"
+
+// Checkpoint defines when and where the training state is saved
+type Checkpoint struct {
+	every     int                                     // Save every n epochs (0: never)
+	onImprove bool                                    // Save when the validation error improves
+	dir       string                                  // Output directory
+	keep      int                                     // Number of files kept in the directory (0: all)
+	create    func(epoch int) (io.WriteCloser, error) // Output factory
+}
+
+// Every saves a checkpoint every n epochs
+func (cp *Checkpoint) Every(epochs int) {
+	cp.every = epochs
+}
+
+// OnImprove saves a checkpoint each time the validation error is improved
+func (cp *Checkpoint) OnImprove() {
+	cp.onImprove = true
+}
+
+// ToDir writes the checkpoints in a directory, only the last "keep" files are kept (0: keep all)
+func (cp *Checkpoint) ToDir(dir string, keep int) {
+	cp.dir = dir
+	cp.keep = keep
+	cp.create = func(epoch int) (io.WriteCloser, error) {
+		return os.Create(filepath.Join(dir, fmt.Sprintf(checkpointPattern, epoch)))
+	}
+}
+
+// ToWriter writes the checkpoints using a writer factory
+func (cp *Checkpoint) ToWriter(create func(epoch int) (io.WriteCloser, error)) {
+	cp.dir = ""
+	cp.keep = 0
+	cp.create = create
+}
+
+// shallSave checks if a checkpoint has to be written for the last epoch
+func (cp Checkpoint) shallSave(history History) bool {
+	if cp.create == nil || len(history) == 0 {
+		return false
+	}
+
+	last := history.Last()
+	if cp.every > 0 && (last.Epoch+1)%cp.every == 0 {
+		return true
+	}
+	if cp.onImprove {
+		for _, metrics := range history[:len(history)-1] {
+			if !(last.ValidationError < metrics.ValidationError) {
+				return false
+			}
+		}
+		return !math.IsNaN(last.ValidationError)
+	}
+	return false
+}
+
+// save writes the checkpoint and rotates the files
+func (cp Checkpoint) save(state trainingState) error {
+	w, err := cp.create(state.history.Last().Epoch)
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(w).Encode(state)
+	if errClose := w.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return err
+	}
+	return cp.rotate()
+}
+
+// rotate removes the oldest checkpoints files of the directory
+func (cp Checkpoint) rotate() error {
+	if cp.dir == "" || cp.keep <= 0 {
+		return nil
+	}
+
+	files, err := checkpointFiles(cp.dir)
+	if err != nil {
+		return err
+	}
+	for len(files) > cp.keep {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// checkpointFiles lists the checkpoint files of a directory, oldest first
+func checkpointFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "checkpoint-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// LatestCheckpoint returns the path of the last checkpoint written in a directory
+func LatestCheckpoint(dir string) (string, error) {
+	files, err := checkpointFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no checkpoint found in %q", dir)
+	}
+	return files[len(files)-1], nil
+}
+
+// trainingState is the content of a checkpoint
+type trainingState struct {
+	network *Network      // Weights and learning rate
+	elapsed time.Duration // Training duration before the checkpoint
+	random  uint64        // Random source state
+	history History       // Metrics of all processed epochs
+}
+
+// for marshal/unmarshal a checkpoint
+type exportTrainingState struct {
+	Epoch   int             `json:"epoch"`
+	Elapsed time.Duration   `json:"elapsed"`
+	Random  uint64          `json:"random"`
+	History History         `json:"history"`
+	Network json.RawMessage `json:"network"`
+}
+
+func (ts trainingState) MarshalJSON() ([]byte, error) {
+	network, err := ts.network.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(exportTrainingState{
+		Epoch:   ts.history.Last().Epoch,
+		Elapsed: ts.elapsed,
+		Random:  ts.random,
+		History: ts.history,
+		Network: network,
+	})
+}
+
+func (ts *trainingState) UnmarshalJSON(data []byte) error {
+	var exp exportTrainingState
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+	if len(exp.History) == 0 || exp.History.Last().Epoch != exp.Epoch {
+		return fmt.Errorf("checkpoint history does not match epoch %d", exp.Epoch)
+	}
+
+	net := Network{}
+	err = net.UnmarshalJSON(exp.Network)
+	if err != nil {
+		return err
+	}
+
+	*ts = trainingState{
+		network: &net,
+		elapsed: exp.Elapsed,
+		random:  exp.Random,
+		history: exp.History,
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package mlp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// nopCloser adds a Close method to a buffer
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	xData := [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+	yData := [][]float64{{0}, {1}, {1}, {0}}
+
+	newNet := func() Network {
+		rand.Seed(42)
+		net := NewNetwork(0.3, 2)
+		net.AddLayer(LinearBuilder{}, 3, Sigmoid{})
+		net.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+		return net
+	}
+
+	Convey("checkpoint", t, func() {
+		Convey("every n epochs with rotation", func() {
+			dir := t.TempDir()
+			net := newNet()
+			net.Checkpoint.Every(3)
+			net.Checkpoint.ToDir(dir, 2)
+			net.Stop.OnEpoch(11)
+			_, err := net.Train(ctx, xData, yData)
+			So(err, ShouldBeNil)
+
+			files, err := filepath.Glob(filepath.Join(dir, "*"))
+			So(err, ShouldBeNil)
+			So(files, ShouldResemble, []string{
+				filepath.Join(dir, "checkpoint-000008.json"),
+				filepath.Join(dir, "checkpoint-000011.json"),
+			})
+
+			latest, err := LatestCheckpoint(dir)
+			So(err, ShouldBeNil)
+			So(latest, ShouldEqual, files[1])
+		})
+
+		Convey("on improve", func() {
+			var epochs []int
+			net := newNet()
+			net.SetValidation(xData, yData)
+			net.Checkpoint.OnImprove()
+			net.Checkpoint.ToWriter(func(epoch int) (io.WriteCloser, error) {
+				epochs = append(epochs, epoch)
+				return nopCloser{&bytes.Buffer{}}, nil
+			})
+			net.Stop.OnEpoch(9)
+			term, err := net.Train(ctx, xData, yData)
+			So(err, ShouldBeNil)
+			So(epochs, ShouldNotBeEmpty)
+			So(epochs[0], ShouldEqual, 0)
+
+			// Each saved epoch improves the best error
+			history := term.History()
+			for i := 1; i < len(epochs); i++ {
+				So(history[epochs[i]].ValidationError, ShouldBeLessThan, history[epochs[i-1]].ValidationError)
+			}
+		})
+
+		Convey("resume", func() {
+			// Full run
+			net1 := newNet()
+			net1.Stop.OnEpoch(20)
+			term1, err := net1.Train(ctx, xData, yData)
+			So(err, ShouldBeNil)
+
+			// Interrupted run
+			dir := t.TempDir()
+			net2 := newNet()
+			net2.Checkpoint.Every(10)
+			net2.Checkpoint.ToDir(dir, 0)
+			net2.Stop.OnEpoch(12)
+			_, err = net2.Train(ctx, xData, yData)
+			So(err, ShouldBeNil)
+
+			// Resume
+			file, err := os.Open(filepath.Join(dir, "checkpoint-000009.json"))
+			So(err, ShouldBeNil)
+			defer file.Close()
+
+			net3 := Network{}
+			net3.Stop.OnEpoch(20)
+			term3, err := net3.Resume(ctx, file, xData, yData)
+			So(err, ShouldBeNil)
+			So(term3.History(), ShouldHaveLength, 21)
+			So(term3.History().Last().MeanSquaredError, ShouldEqual, term1.History().Last().MeanSquaredError)
+			So(net3.layers, ShouldResemble, net1.layers)
+			So(net3.random, ShouldResemble, net1.random)
+		})
+
+		Convey("invalid checkpoint", func() {
+			net := Network{}
+			_, err := net.Resume(ctx, bytes.NewBufferString(`{"epoch": 3, "history": []}`), xData, yData)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
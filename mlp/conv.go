@@ -0,0 +1,298 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Shape of image values, stored channel by channel, then row by row
+type Shape struct {
+	Channels int `json:"channels"`
+	Height   int `json:"height"`
+	Width    int `json:"width"`
+}
+
+// Size returns the number of values
+func (s Shape) Size() int {
+	return s.Channels * s.Height * s.Width
+}
+
+// index of a value in the flat vector
+func (s Shape) index(c, i, j int) int {
+	return (c*s.Height+i)*s.Width + j
+}
+
+// check that all dimensions are positive
+func (s Shape) check() error {
+	if s.Channels <= 0 || s.Height <= 0 || s.Width <= 0 {
+		return fmt.Errorf("shape %s shall be positive", s)
+	}
+	return nil
+}
+
+func (s Shape) String() string {
+	return fmt.Sprintf("%dx%dx%d", s.Channels, s.Height, s.Width)
+}
+
+// imageLayer is implemented by layers processing images of a given shape
+type imageLayer interface {
+	InputShape() Shape
+}
+
+// Conv2D layer applies filters sliding over the image (cross-correlation)
+// y[f, i, j] = b[f] + ∑ w[f, c, u, v] * x[c, i*stride + u - padding, j*stride + v - padding]
+type Conv2D struct {
+	in      Shape // Input shape
+	kernel  int   // Size of the square filters
+	stride  int   // Step between two positions of the filters
+	padding int   // Number of zeros added around the image
+
+	filters // d = filters x (channels * kernel * kernel)
+}
+
+// NewConv2D allocates a convolution layer, weights are initialized with the He initialization
+func NewConv2D(in Shape, count, kernel, stride, padding int) (Conv2D, error) {
+	if count <= 0 {
+		return Conv2D{}, fmt.Errorf("number of filters shall be positive")
+	}
+	conv := Conv2D{
+		in:      in,
+		kernel:  kernel,
+		stride:  stride,
+		padding: padding,
+		filters: filters{biases: newVector(count)},
+	}
+	if err := conv.check(); err != nil {
+		return Conv2D{}, err
+	}
+	conv.filters = newFilters(count, in.Channels*kernel*kernel)
+	return conv, nil
+}
+
+// check the hyper parameters and the shapes
+func (conv Conv2D) check() error {
+	switch {
+	case conv.in.check() != nil:
+		return conv.in.check()
+	case conv.kernel <= 0 || conv.stride <= 0 || conv.padding < 0:
+		return fmt.Errorf("kernel and stride shall be positive, padding shall not be negative")
+	case len(conv.biases) == 0:
+		return fmt.Errorf("number of filters shall be positive")
+	}
+	if conv.in.Height+2*conv.padding < conv.kernel || conv.in.Width+2*conv.padding < conv.kernel {
+		return fmt.Errorf("kernel %d does not fit in shape %s", conv.kernel, conv.in)
+	}
+	return nil
+}
+
+// InputShape returns the shape of the input image
+func (conv Conv2D) InputShape() Shape {
+	return conv.in
+}
+
+// OutputShape returns the shape of the output image (one channel per filter)
+func (conv Conv2D) OutputShape() Shape {
+	size := func(n int) int {
+		return (n+2*conv.padding-conv.kernel)/conv.stride + 1
+	}
+	return Shape{
+		Channels: len(conv.biases),
+		Height:   size(conv.in.Height),
+		Width:    size(conv.in.Width),
+	}
+}
+
+// taps calls fn with the weight index and the input index of each value under the filter at output position (i, j),
+// values in the padding are skipped
+func (conv Conv2D) taps(i, j int, fn func(k, xi int)) {
+	var k int
+	for c := 0; c < conv.in.Channels; c++ {
+		for u := 0; u < conv.kernel; u++ {
+			for v := 0; v < conv.kernel; v++ {
+				row, col := i*conv.stride+u-conv.padding, j*conv.stride+v-conv.padding
+				if row >= 0 && row < conv.in.Height && col >= 0 && col < conv.in.Width {
+					fn(k, conv.in.index(c, row, col))
+				}
+				k++
+			}
+		}
+	}
+}
+
+// FeedForward applies each filter at each position
+func (conv Conv2D) FeedForward(x []float64) []float64 {
+	positions, taps := conv.positions()
+	return conv.forward(x, positions, taps)
+}
+
+// BackPropagation computes the x gradient
+func (conv Conv2D) BackPropagation(x, yGrad []float64) []float64 {
+	positions, taps := conv.positions()
+	return conv.backward(x, yGrad, positions, taps)
+}
+
+// positions returns the number of output positions (per filter) and lists the taps of the filter at each of them
+func (conv Conv2D) positions() (int, func(p int, fn func(k, xi int))) {
+	out := conv.OutputShape()
+	return out.Height * out.Width, func(p int, fn func(k, xi int)) {
+		conv.taps(p/out.Width, p%out.Width, fn)
+	}
+}
+
+func (conv Conv2D) Type() string {
+	return "conv2d"
+}
+
+// summary describes the layer
+func (conv Conv2D) summary(in int) LayerSummary {
+	out := conv.OutputShape()
+	return LayerSummary{
+		Type:       conv.Type(),
+		Inputs:     in,
+		Outputs:    out.Size(),
+		Shape:      out.String(),
+		Parameters: conv.parameters(),
+	}
+}
+
+type exportConv2D struct {
+	Shape   Shape  `json:"shape"`
+	Kernel  int    `json:"kernel"`
+	Stride  int    `json:"stride"`
+	Padding int    `json:"padding,omitempty"`
+	Weights matrix `json:"weights"`
+	Biases  vector `json:"biases"`
+}
+
+func (conv Conv2D) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportConv2D{
+		Shape:   conv.in,
+		Kernel:  conv.kernel,
+		Stride:  conv.stride,
+		Padding: conv.padding,
+		Weights: conv.weights,
+		Biases:  conv.biases,
+	})
+}
+
+func (conv *Conv2D) UnmarshalJSON(data []byte) error {
+	exp := exportConv2D{}
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+
+	*conv = Conv2D{
+		in:      exp.Shape,
+		kernel:  exp.Kernel,
+		stride:  exp.Stride,
+		padding: exp.Padding,
+		filters: filters{weights: exp.Weights, biases: exp.Biases},
+	}
+	if err := conv.check(); err != nil {
+		return err
+	}
+	return conv.filters.load(conv.in.Channels * conv.kernel * conv.kernel)
+}
+
+// filters holds the weights and the biases of a convolution layer, one row of weights per filter
+type filters struct {
+	weights     matrix // d = filters x values under the filter
+	weightsGrad matrix // d = filters x values under the filter
+	biases      vector // d = filters
+	biasesGrad  vector // d = filters
+}
+
+// newFilters allocates filters, weights are initialized with the He initialization
+func newFilters(count, fanIn int) filters {
+	weights := newMatrix(count, fanIn)
+	return filters{
+		weights: weights.iter(func(f, k int) {
+			weights[f][k] = He{}.Weight(fanIn, count)
+		}),
+		weightsGrad: newMatrix(count, fanIn).zeros(),
+		biases:      newVector(count).zeros(),
+		biasesGrad:  newVector(count).zeros(),
+	}
+}
+
+// load checks loaded weights and allocates the gradients
+func (fs *filters) load(fanIn int) error {
+	if len(fs.weights) != len(fs.biases) {
+		return fmt.Errorf("weights (%d) do not match biases (%d)", len(fs.weights), len(fs.biases))
+	}
+	for f, w := range fs.weights {
+		if len(w) != fanIn {
+			return fmt.Errorf("weights of filter %d (%d) do not match %d values", f, len(w), fanIn)
+		}
+	}
+	fs.weightsGrad = newMatrix(len(fs.weights), fanIn).zeros()
+	fs.biasesGrad = newVector(len(fs.biases)).zeros()
+	return nil
+}
+
+// forward computes y[f, p] = b[f] + ∑ w[f, k] * x[xi] at each output position p,
+// where taps lists the (k, xi) under the filter at position p
+func (fs filters) forward(x []float64, positions int, taps func(p int, fn func(k, xi int))) []float64 {
+	y := newVector(len(fs.biases) * positions)
+	for f, w := range fs.weights {
+		for p := 0; p < positions; p++ {
+			sum := fs.biases[f]
+			taps(p, func(k, xi int) {
+				sum += w[k] * x[xi]
+			})
+			y[f*positions+p] = sum
+		}
+	}
+	return y
+}
+
+// backward accumulates the gradients of the filters and computes the x gradient
+func (fs filters) backward(x, yGrad []float64, positions int, taps func(p int, fn func(k, xi int))) []float64 {
+	xGrad := newVector(len(x)).zeros()
+	for f, w := range fs.weights {
+		wGrad := fs.weightsGrad[f]
+		for p := 0; p < positions; p++ {
+			grad := yGrad[f*positions+p]
+			fs.biasesGrad[f] += grad
+			taps(p, func(k, xi int) {
+				wGrad[k] += x[xi] * grad
+				xGrad[xi] += w[k] * grad
+			})
+		}
+	}
+	return xGrad
+}
+
+// Update weights and biases
+func (fs filters) Update(learningRate float64) {
+	fs.biases.iter(func(f int) {
+		fs.biases[f] -= learningRate * fs.biasesGrad[f]
+	})
+	fs.weights.iter(func(f, k int) {
+		fs.weights[f][k] -= learningRate * fs.weightsGrad[f][k]
+	})
+
+	// Clear gradients
+	fs.biasesGrad.zeros()
+	fs.weightsGrad.zeros()
+}
+
+// Parameters returns the biases and the weights of each filter
+func (fs filters) Parameters() []Parameter {
+	params := make([]Parameter, 0, 1+len(fs.weights))
+	params = append(params, Parameter{Name: "biases", Values: fs.biases, Grads: fs.biasesGrad})
+	for f := range fs.weights {
+		params = append(params, Parameter{
+			Name:   fmt.Sprintf("weights[%d]", f),
+			Values: fs.weights[f],
+			Grads:  fs.weightsGrad[f],
+		})
+	}
+	return params
+}
+
+// parameters counts the weights and the biases
+func (fs filters) parameters() int {
+	return len(fs.weights)*len(fs.weights[0]) + len(fs.biases)
+}
@@ -0,0 +1,289 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Sequence returns the shape of a sequence of values (channels x 1 x length), stored channel by channel
+func Sequence(channels, length int) Shape {
+	return Shape{Channels: channels, Height: 1, Width: length}
+}
+
+// Padding is the strategy used on the borders of a sequence
+type Padding string
+
+const (
+	ValidPadding  Padding = ""       // No padding, the filter stays within the sequence
+	SamePadding   Padding = "same"   // Zeros on both sides, the length is kept (with a stride of 1)
+	CausalPadding Padding = "causal" // Zeros on the left only, an output never depends on later values
+)
+
+// Conv1DOptions are the optional hyper parameters of a 1D convolution
+type Conv1DOptions struct {
+	Stride   int     // Step between two positions of the filters (default: 1)
+	Dilation int     // Step between two values under the filters (default: 1)
+	Padding  Padding // Padding of the borders (default: valid)
+}
+
+// Conv1D layer applies filters sliding along a sequence (cross-correlation)
+// y[f, t] = b[f] + ∑ w[f, c, u] * x[c, t*stride + u*dilation - left padding]
+type Conv1D struct {
+	in      Shape         // Input shape (channels x 1 x length)
+	kernel  int           // Size of the filters
+	options Conv1DOptions // Stride, dilation and padding
+
+	filters // d = filters x (channels * kernel)
+}
+
+// NewConv1D allocates a 1D convolution layer, weights are initialized with the He initialization
+func NewConv1D(in Shape, count, kernel int, options Conv1DOptions) (Conv1D, error) {
+	if count <= 0 {
+		return Conv1D{}, fmt.Errorf("number of filters shall be positive")
+	}
+	if options.Stride == 0 {
+		options.Stride = 1
+	}
+	if options.Dilation == 0 {
+		options.Dilation = 1
+	}
+	conv := Conv1D{
+		in:      in,
+		kernel:  kernel,
+		options: options,
+		filters: filters{biases: newVector(count)},
+	}
+	if err := conv.check(); err != nil {
+		return Conv1D{}, err
+	}
+	conv.filters = newFilters(count, in.Channels*kernel)
+	return conv, nil
+}
+
+// check the hyper parameters and the shapes
+func (conv Conv1D) check() error {
+	opts := conv.options
+	switch {
+	case conv.in.check() != nil:
+		return conv.in.check()
+	case conv.in.Height != 1:
+		return fmt.Errorf("shape %s is not a sequence", conv.in)
+	case conv.kernel <= 0 || opts.Stride <= 0 || opts.Dilation <= 0:
+		return fmt.Errorf("kernel, stride and dilation shall be positive")
+	case opts.Padding != ValidPadding && opts.Padding != SamePadding && opts.Padding != CausalPadding:
+		return fmt.Errorf("unknown padding %q", opts.Padding)
+	case len(conv.biases) == 0:
+		return fmt.Errorf("number of filters shall be positive")
+	}
+	if left, right := conv.paddings(); conv.in.Width+left+right < conv.span() {
+		return fmt.Errorf("kernel %d (dilation %d) does not fit in shape %s", conv.kernel, opts.Dilation, conv.in)
+	}
+	return nil
+}
+
+// InputShape returns the shape of the input sequence
+func (conv Conv1D) InputShape() Shape {
+	return conv.in
+}
+
+// span returns the number of values covered by a filter
+func (conv Conv1D) span() int {
+	return (conv.kernel-1)*conv.options.Dilation + 1
+}
+
+// paddings returns the number of zeros added on the left and on the right of the sequence
+func (conv Conv1D) paddings() (int, int) {
+	pad := conv.span() - 1
+	switch conv.options.Padding {
+	case SamePadding:
+		return pad / 2, pad - pad/2
+	case CausalPadding:
+		return pad, 0
+	default:
+		return 0, 0
+	}
+}
+
+// OutputShape returns the shape of the output sequence (one channel per filter)
+func (conv Conv1D) OutputShape() Shape {
+	left, right := conv.paddings()
+	return Sequence(len(conv.biases), (conv.in.Width+left+right-conv.span())/conv.options.Stride+1)
+}
+
+// positions returns the number of output positions (per filter) and lists the taps of the filter at each of them,
+// values in the padding are skipped
+func (conv Conv1D) positions() (int, func(p int, fn func(k, xi int))) {
+	left, _ := conv.paddings()
+	return conv.OutputShape().Width, func(t int, fn func(k, xi int)) {
+		var k int
+		for c := 0; c < conv.in.Channels; c++ {
+			for u := 0; u < conv.kernel; u++ {
+				pos := t*conv.options.Stride + u*conv.options.Dilation - left
+				if pos >= 0 && pos < conv.in.Width {
+					fn(k, conv.in.index(c, 0, pos))
+				}
+				k++
+			}
+		}
+	}
+}
+
+// FeedForward applies each filter at each position
+func (conv Conv1D) FeedForward(x []float64) []float64 {
+	positions, taps := conv.positions()
+	return conv.forward(x, positions, taps)
+}
+
+// BackPropagation computes the x gradient
+func (conv Conv1D) BackPropagation(x, yGrad []float64) []float64 {
+	positions, taps := conv.positions()
+	return conv.backward(x, yGrad, positions, taps)
+}
+
+func (conv Conv1D) Type() string {
+	return "conv1d"
+}
+
+// summary describes the layer
+func (conv Conv1D) summary(in int) LayerSummary {
+	out := conv.OutputShape()
+	return LayerSummary{
+		Type:       conv.Type(),
+		Inputs:     in,
+		Outputs:    out.Size(),
+		Shape:      out.String(),
+		Parameters: conv.parameters(),
+	}
+}
+
+type exportConv1D struct {
+	Shape    Shape   `json:"shape"`
+	Kernel   int     `json:"kernel"`
+	Stride   int     `json:"stride"`
+	Dilation int     `json:"dilation"`
+	Padding  Padding `json:"padding,omitempty"`
+	Weights  matrix  `json:"weights"`
+	Biases   vector  `json:"biases"`
+}
+
+func (conv Conv1D) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportConv1D{
+		Shape:    conv.in,
+		Kernel:   conv.kernel,
+		Stride:   conv.options.Stride,
+		Dilation: conv.options.Dilation,
+		Padding:  conv.options.Padding,
+		Weights:  conv.weights,
+		Biases:   conv.biases,
+	})
+}
+
+func (conv *Conv1D) UnmarshalJSON(data []byte) error {
+	exp := exportConv1D{}
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+
+	*conv = Conv1D{
+		in:      exp.Shape,
+		kernel:  exp.Kernel,
+		options: Conv1DOptions{Stride: exp.Stride, Dilation: exp.Dilation, Padding: exp.Padding},
+		filters: filters{weights: exp.Weights, biases: exp.Biases},
+	}
+	if err := conv.check(); err != nil {
+		return err
+	}
+	return conv.filters.load(conv.in.Channels * conv.kernel)
+}
+
+// GlobalAveragePool1D layer computes the mean of each channel of a sequence
+type GlobalAveragePool1D struct {
+	in Shape // Input shape (channels x 1 x length)
+}
+
+// NewGlobalAveragePool1D builds a global average pooling layer
+func NewGlobalAveragePool1D(in Shape) (GlobalAveragePool1D, error) {
+	pool := GlobalAveragePool1D{in: in}
+	return pool, pool.check()
+}
+
+// check the shape
+func (pool GlobalAveragePool1D) check() error {
+	if err := pool.in.check(); err != nil {
+		return err
+	}
+	if pool.in.Height != 1 {
+		return fmt.Errorf("shape %s is not a sequence", pool.in)
+	}
+	return nil
+}
+
+// InputShape returns the shape of the input sequence
+func (pool GlobalAveragePool1D) InputShape() Shape {
+	return pool.in
+}
+
+// OutputShape returns the shape of the output (one value per channel)
+func (pool GlobalAveragePool1D) OutputShape() Shape {
+	return Sequence(pool.in.Channels, 1)
+}
+
+// FeedForward computes the mean of each channel
+func (pool GlobalAveragePool1D) FeedForward(x []float64) []float64 {
+	length := pool.in.Width
+	y := newVector(pool.in.Channels).zeros()
+	return y.iter(func(c int) {
+		for t := 0; t < length; t++ {
+			y[c] += x[c*length+t]
+		}
+		y[c] /= float64(length)
+	})
+}
+
+// BackPropagation shares the y gradient between the values of each channel
+func (pool GlobalAveragePool1D) BackPropagation(x, yGrad []float64) []float64 {
+	length := pool.in.Width
+	xGrad := newVector(len(x))
+	return xGrad.iter(func(i int) {
+		xGrad[i] = yGrad[i/length] / float64(length)
+	})
+}
+
+// Update does nothing
+func (pool GlobalAveragePool1D) Update(learningRate float64) {
+	// No processing
+}
+
+func (pool GlobalAveragePool1D) Type() string {
+	return "global-avg-pool1d"
+}
+
+// summary describes the layer
+func (pool GlobalAveragePool1D) summary(in int) LayerSummary {
+	out := pool.OutputShape()
+	return LayerSummary{
+		Type:    pool.Type(),
+		Inputs:  in,
+		Outputs: out.Size(),
+		Shape:   out.String(),
+	}
+}
+
+type exportGlobalAveragePool1D struct {
+	Shape Shape `json:"shape"`
+}
+
+func (pool GlobalAveragePool1D) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportGlobalAveragePool1D{Shape: pool.in})
+}
+
+func (pool *GlobalAveragePool1D) UnmarshalJSON(data []byte) error {
+	exp := exportGlobalAveragePool1D{}
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+	pool.in = exp.Shape
+	return pool.check()
+}
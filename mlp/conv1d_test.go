@@ -0,0 +1,147 @@
+package mlp
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConv1D(t *testing.T) {
+	Convey("sequence layers", t, func() {
+		rand.Seed(42)
+		seq := Sequence(1, 5)
+		x := []float64{1, 2, 3, 4, 5}
+
+		// newConv builds a single filter convolution
+		newConv := func(weights []float64, options Conv1DOptions) Conv1D {
+			conv, err := NewConv1D(seq, 1, len(weights), options)
+			So(err, ShouldBeNil)
+			copy(conv.weights[0], weights)
+			return conv
+		}
+
+		Convey("conv1d", func() {
+			conv := newConv([]float64{1, 1}, Conv1DOptions{})
+			So(conv.OutputShape(), ShouldResemble, Sequence(1, 4))
+			So(conv.FeedForward(x), ShouldResemble, []float64{3, 5, 7, 9})
+
+			dilated := newConv([]float64{1, 1}, Conv1DOptions{Dilation: 2})
+			So(dilated.FeedForward(x), ShouldResemble, []float64{4, 6, 8})
+
+			strided := newConv([]float64{1}, Conv1DOptions{Stride: 2})
+			So(strided.FeedForward(x), ShouldResemble, []float64{1, 3, 5})
+
+			same := newConv([]float64{1, 1, 1}, Conv1DOptions{Padding: SamePadding})
+			So(same.FeedForward(x), ShouldResemble, []float64{3, 6, 9, 12, 9})
+
+			sensors, err := NewConv1D(Sequence(3, 50), 8, 5, Conv1DOptions{Padding: CausalPadding, Dilation: 2})
+			So(err, ShouldBeNil)
+			So(sensors.summary(150), ShouldResemble, LayerSummary{Type: "conv1d", Inputs: 150, Outputs: 400, Shape: "8x1x50", Parameters: 128})
+		})
+
+		Convey("causal padding", func() {
+			causal := newConv([]float64{1, 0, 0}, Conv1DOptions{Padding: CausalPadding, Dilation: 2})
+			So(causal.FeedForward(x), ShouldResemble, []float64{0, 0, 0, 0, 1}) // x[t-4]
+
+			// An output never depends on later values
+			causal = newConv([]float64{0.3, -0.2, 0.5}, Conv1DOptions{Padding: CausalPadding})
+			y := causal.FeedForward(x)
+			y2 := causal.FeedForward([]float64{1, 2, 3, 4, 42})
+			So(y2[:4], ShouldResemble, y[:4])
+			So(y2[4], ShouldNotEqual, y[4])
+		})
+
+		Convey("global average pooling", func() {
+			pool, err := NewGlobalAveragePool1D(Sequence(2, 3))
+			So(err, ShouldBeNil)
+			So(pool.OutputShape(), ShouldResemble, Sequence(2, 1))
+			So(pool.FeedForward([]float64{1, 2, 3, 4, 6, 8}), ShouldResemble, []float64{2, 6})
+			So(pool.BackPropagation([]float64{1, 2, 3, 4, 6, 8}, []float64{3, 6}), ShouldResemble, []float64{1, 1, 1, 2, 2, 2})
+		})
+
+		Convey("gradients", func() {
+			in := Sequence(2, 7)
+			x := make([]float64, in.Size())
+			for i := range x {
+				x[i] = rand.Float64()
+			}
+			causal, err := NewConv1D(in, 3, 3, Conv1DOptions{Padding: CausalPadding, Dilation: 2})
+			So(err, ShouldBeNil)
+			same, err := NewConv1D(causal.OutputShape(), 2, 2, Conv1DOptions{Padding: SamePadding, Stride: 2})
+			So(err, ShouldBeNil)
+			pool, err := NewGlobalAveragePool1D(same.OutputShape())
+			So(err, ShouldBeNil)
+
+			result, err := GradCheck(x, []float64{1, 0}, 1e-6,
+				causal, NewActivatorLayer(Htan{}), same, pool, NewLinear(2, 2), NewActivatorLayer(Sigmoid{}))
+			So(err, ShouldBeNil)
+			So(result.MaxError().Error, ShouldBeLessThan, 1e-6)
+		})
+
+		Convey("network", func() {
+			// Rising (class 1) or falling (class 0) sensor windows
+			var xData, yData [][]float64
+			for k := 0; k < 10; k++ {
+				rising, falling := make([]float64, 8), make([]float64, 8)
+				for t := range rising {
+					rising[t] = float64(k+t) / 10
+					falling[t] = float64(k+8-t) / 10
+				}
+				xData = append(xData, rising, falling)
+				yData = append(yData, []float64{1}, []float64{0})
+			}
+
+			net := NewNetwork(0.1, 8)
+			conv, err := NewConv1D(Sequence(1, 8), 4, 2, Conv1DOptions{Padding: CausalPadding})
+			So(err, ShouldBeNil)
+			So(net.Add(conv), ShouldBeNil)
+			So(net.Add(NewActivatorLayer(Htan{})), ShouldBeNil)
+			shape, ok := net.OutputShape()
+			So(ok, ShouldBeTrue)
+			pool, err := NewGlobalAveragePool1D(shape)
+			So(err, ShouldBeNil)
+			So(net.Add(pool), ShouldBeNil)
+			net.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+			So(net.Neurons(), ShouldResemble, []int{8, 32, 4, 1})
+
+			net.Stop.OnEpoch(300)
+			_, err = net.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+			eval, err := net.Evaluate(xData, yData)
+			So(err, ShouldBeNil)
+			So(eval.Accuracy, ShouldEqual, 1)
+
+			data, err := json.Marshal(net)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, `"padding":"causal"`)
+			var net2 Network
+			So(json.Unmarshal(data, &net2), ShouldBeNil)
+			So(net2.layers, ShouldResemble, net.layers)
+			So(net2.Predict(xData[0]), ShouldResemble, net.Predict(xData[0]))
+		})
+
+		Convey("errors", func() {
+			_, err := NewConv1D(seq, 0, 2, Conv1DOptions{})
+			So(err, ShouldBeError, "number of filters shall be positive")
+			_, err = NewConv1D(Shape{Channels: 1, Height: 2, Width: 5}, 1, 2, Conv1DOptions{})
+			So(err, ShouldBeError, "shape 1x2x5 is not a sequence")
+			_, err = NewConv1D(seq, 1, 3, Conv1DOptions{Dilation: 3})
+			So(err, ShouldBeError, "kernel 3 (dilation 3) does not fit in shape 1x1x5")
+			_, err = NewConv1D(seq, 1, 3, Conv1DOptions{Dilation: 3, Stride: 2})
+			So(err, ShouldBeError, "kernel 3 (dilation 3) does not fit in shape 1x1x5")
+			_, err = NewConv1D(seq, 1, 2, Conv1DOptions{Stride: -1})
+			So(err, ShouldBeError, "kernel, stride and dilation shall be positive")
+			_, err = NewConv1D(seq, 1, 2, Conv1DOptions{Padding: "full"})
+			So(err, ShouldBeError, `unknown padding "full"`)
+			_, err = NewGlobalAveragePool1D(Shape{Channels: 1, Height: 2, Width: 5})
+			So(err, ShouldBeError, "shape 1x2x5 is not a sequence")
+
+			var conv Conv1D
+			err = conv.UnmarshalJSON([]byte(`{"shape":{"channels":1,"height":1,"width":5},"kernel":2,"stride":1,"dilation":1,"weights":[[1]],"biases":[0]}`))
+			So(err, ShouldBeError, "weights of filter 0 (1) do not match 2 values")
+		})
+	})
+}
@@ -0,0 +1,155 @@
+package mlp
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// lines draws 5x5 images with a horizontal (class 0) or a vertical (class 1) line
+func lines() ([][]float64, [][]float64) {
+	var xData, yData [][]float64
+	for k := 0; k < 5; k++ {
+		horizontal, vertical := make([]float64, 25), make([]float64, 25)
+		for l := 0; l < 5; l++ {
+			horizontal[k*5+l] = 1
+			vertical[l*5+k] = 1
+		}
+		xData = append(xData, horizontal, vertical)
+		yData = append(yData, []float64{1, 0}, []float64{0, 1})
+	}
+	return xData, yData
+}
+
+func TestConv(t *testing.T) {
+	Convey("image layers", t, func() {
+		rand.Seed(42)
+		image := Shape{Channels: 1, Height: 3, Width: 3}
+		x := []float64{
+			1, 2, 3,
+			4, 5, 6,
+			7, 8, 9,
+		}
+
+		Convey("conv2d", func() {
+			conv, err := NewConv2D(image, 1, 2, 1, 0)
+			So(err, ShouldBeNil)
+			copy(conv.weights[0], []float64{1, 0, 0, 1})
+			conv.biases[0] = 0.5
+			So(conv.OutputShape(), ShouldResemble, Shape{Channels: 1, Height: 2, Width: 2})
+			So(conv.FeedForward(x), ShouldResemble, []float64{6.5, 8.5, 12.5, 14.5})
+
+			// Padding and stride
+			conv, err = NewConv2D(image, 1, 3, 2, 1)
+			So(err, ShouldBeNil)
+			So(conv.OutputShape(), ShouldResemble, Shape{Channels: 1, Height: 2, Width: 2})
+			copy(conv.weights[0], []float64{0, 0, 0, 0, 1, 0, 0, 0, 0})
+			So(conv.FeedForward(x), ShouldResemble, []float64{1, 3, 7, 9})
+
+			mnist, err := NewConv2D(Shape{Channels: 1, Height: 28, Width: 28}, 8, 3, 1, 1)
+			So(err, ShouldBeNil)
+			So(mnist.OutputShape(), ShouldResemble, Shape{Channels: 8, Height: 28, Width: 28})
+			So(mnist.summary(784), ShouldResemble, LayerSummary{Type: "conv2d", Inputs: 784, Outputs: 6272, Shape: "8x28x28", Parameters: 80})
+		})
+
+		Convey("pooling", func() {
+			maxPool, err := NewMaxPool2D(image, 2, 1)
+			So(err, ShouldBeNil)
+			So(maxPool.FeedForward(x), ShouldResemble, []float64{5, 6, 8, 9})
+			So(maxPool.BackPropagation(x, []float64{1, 2, 3, 4}), ShouldResemble, []float64{0, 0, 0, 0, 1, 2, 0, 3, 4})
+
+			avgPool, err := NewAvgPool2D(image, 2, 1)
+			So(err, ShouldBeNil)
+			So(avgPool.FeedForward(x), ShouldResemble, []float64{3, 4, 6, 7})
+			So(avgPool.BackPropagation(x, []float64{4, 0, 0, 0}), ShouldResemble, []float64{1, 1, 0, 1, 1, 0, 0, 0, 0})
+		})
+
+		Convey("gradients", func() {
+			in := Shape{Channels: 2, Height: 5, Width: 5}
+			x := make([]float64, in.Size())
+			for i := range x {
+				x[i] = rand.Float64()
+			}
+			conv, err := NewConv2D(in, 3, 3, 2, 1)
+			So(err, ShouldBeNil)
+			maxPool, err := NewMaxPool2D(conv.OutputShape(), 2, 1)
+			So(err, ShouldBeNil)
+			avgPool, err := NewAvgPool2D(maxPool.OutputShape(), 2, 1)
+			So(err, ShouldBeNil)
+			flatten, err := NewFlatten(avgPool.OutputShape())
+			So(err, ShouldBeNil)
+
+			result, err := GradCheck(x, []float64{1, 0}, 1e-6,
+				conv, NewActivatorLayer(Htan{}), maxPool, avgPool, flatten, NewLinear(3, 2), NewActivatorLayer(Sigmoid{}))
+			So(err, ShouldBeNil)
+			So(result.MaxError().Error, ShouldBeLessThan, 1e-6)
+		})
+
+		Convey("network", func() {
+			net := NewNetwork(0.5, 25)
+			in := Shape{Channels: 1, Height: 5, Width: 5}
+			conv, err := NewConv2D(in, 2, 3, 1, 1)
+			So(err, ShouldBeNil)
+			So(net.Add(conv), ShouldBeNil)
+			So(net.Add(NewActivatorLayer(ReLU{})), ShouldBeNil)
+			shape, ok := net.OutputShape()
+			So(ok, ShouldBeTrue)
+			So(shape, ShouldResemble, Shape{Channels: 2, Height: 5, Width: 5})
+
+			pool, err := NewMaxPool2D(shape, 5, 5)
+			So(err, ShouldBeNil)
+			So(net.Add(pool), ShouldBeNil)
+			flatten, err := NewFlatten(pool.OutputShape())
+			So(err, ShouldBeNil)
+			So(net.Add(flatten), ShouldBeNil)
+			_, ok = net.OutputShape()
+			So(ok, ShouldBeFalse)
+			net.AddLayer(LinearBuilder{}, 2, Sigmoid{})
+			So(net.Neurons(), ShouldResemble, []int{25, 50, 2, 2, 2})
+			So(net.Add(pool), ShouldBeError, "max-pool2d layer shape 2x5x5 does not match 2 values")
+
+			sum := net.Summary()
+			So(sum.Layers[2], ShouldResemble, LayerSummary{Type: "max-pool2d", Inputs: 50, Outputs: 2, Shape: "2x1x1"})
+			So(sum.String(), ShouldContainSubstring, "50 (2x5x5)")
+
+			xData, yData := lines()
+			net.Stop.OnEpoch(200)
+			_, err = net.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+			eval, err := net.Evaluate(xData, yData)
+			So(err, ShouldBeNil)
+			So(eval.Accuracy, ShouldEqual, 1)
+
+			data, err := json.Marshal(net)
+			So(err, ShouldBeNil)
+			var net2 Network
+			So(json.Unmarshal(data, &net2), ShouldBeNil)
+			So(net2.layers, ShouldResemble, net.layers)
+			So(net2.Predict(xData[0]), ShouldResemble, net.Predict(xData[0]))
+		})
+
+		Convey("errors", func() {
+			_, err := NewConv2D(image, 0, 2, 1, 0)
+			So(err, ShouldBeError, "number of filters shall be positive")
+			_, err = NewConv2D(image, 1, 4, 1, 0)
+			So(err, ShouldBeError, "kernel 4 does not fit in shape 1x3x3")
+			_, err = NewConv2D(image, 1, 2, 0, 0)
+			So(err, ShouldBeError, "kernel and stride shall be positive, padding shall not be negative")
+			_, err = NewMaxPool2D(Shape{Channels: 1, Height: 0, Width: 3}, 2, 1)
+			So(err, ShouldBeError, "shape 1x0x3 shall be positive")
+			_, err = NewAvgPool2D(image, 4, 1)
+			So(err, ShouldBeError, "window 4 does not fit in shape 1x3x3")
+			_, err = NewMaxPool2D(Shape{Channels: 1, Height: 2, Width: 2}, 3, 2)
+			So(err, ShouldBeError, "window 3 does not fit in shape 1x2x2")
+			_, err = NewConv2D(image, 1, 4, 2, 0)
+			So(err, ShouldBeError, "kernel 4 does not fit in shape 1x3x3")
+
+			var conv Conv2D
+			err = conv.UnmarshalJSON([]byte(`{"shape":{"channels":1,"height":3,"width":3},"kernel":2,"stride":1,"weights":[[1,2,3]],"biases":[0]}`))
+			So(err, ShouldBeError, "weights of filter 0 (3) do not match 4 values")
+		})
+	})
+}
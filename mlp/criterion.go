@@ -0,0 +1,250 @@
+package mlp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Criterion is a stop condition checked at the end of each epoch
+type Criterion interface {
+	Reached(history History) bool
+	String() string
+}
+
+// maxEpoch stops when the epoch index is reached
+type maxEpoch int
+
+// MaxEpoch stops the training when the epoch index reaches the given value
+func MaxEpoch(epoch int) Criterion {
+	return maxEpoch(epoch)
+}
+
+func (c maxEpoch) Reached(history History) bool {
+	return len(history) > 0 && history.Last().Epoch >= int(c)
+}
+
+func (c maxEpoch) String() string {
+	return fmt.Sprintf("epoch >= %d", int(c))
+}
+
+// maxDuration stops when the training duration is reached
+type maxDuration time.Duration
+
+// MaxDuration stops the training when the given duration is exceeded
+func MaxDuration(duration time.Duration) Criterion {
+	return maxDuration(duration)
+}
+
+func (c maxDuration) Reached(history History) bool {
+	return len(history) > 0 && history.Last().Duration >= time.Duration(c)
+}
+
+func (c maxDuration) String() string {
+	return fmt.Sprintf("duration >= %s", time.Duration(c))
+}
+
+// minMeanSquaredError stops when the error is low enough
+type minMeanSquaredError float64
+
+// MinMeanSquaredError stops the training when the epoch error is lower or equal to the given value
+func MinMeanSquaredError(mse float64) Criterion {
+	return minMeanSquaredError(mse)
+}
+
+func (c minMeanSquaredError) Reached(history History) bool {
+	return len(history) > 0 && history.Last().MeanSquaredError <= float64(c)
+}
+
+func (c minMeanSquaredError) String() string {
+	return fmt.Sprintf("error <= %g", float64(c))
+}
+
+// plateau stops when the error does not improve anymore
+type plateau struct {
+	patience int
+	minDelta float64
+}
+
+// Plateau stops the training when the best error has not been improved
+// by more than minDelta during the last patience epochs
+func Plateau(patience int, minDelta float64) Criterion {
+	return plateau{
+		patience: patience,
+		minDelta: minDelta,
+	}
+}
+
+func (c plateau) Reached(history History) bool {
+	if c.patience <= 0 || len(history) <= c.patience {
+		return false
+	}
+
+	// Best error before and during the patience window
+	split := len(history) - c.patience
+	before, during := math.Inf(1), math.Inf(1)
+	for i, metrics := range history {
+		if i < split {
+			before = math.Min(before, metrics.MeanSquaredError)
+		} else {
+			during = math.Min(during, metrics.MeanSquaredError)
+		}
+	}
+	return during > before-c.minDelta
+}
+
+func (c plateau) String() string {
+	return fmt.Sprintf("plateau (patience: %d, delta: %g)", c.patience, c.minDelta)
+}
+
+// minValidationAccuracy stops when the validation accuracy is high enough
+type minValidationAccuracy float64
+
+// MinValidationAccuracy stops the training when the accuracy on the validation data
+// is greater or equal to the given value (the validation data shall be set)
+func MinValidationAccuracy(accuracy float64) Criterion {
+	return minValidationAccuracy(accuracy)
+}
+
+func (c minValidationAccuracy) Reached(history History) bool {
+	return len(history) > 0 && history.Last().ValidationAccuracy >= float64(c)
+}
+
+func (c minValidationAccuracy) String() string {
+	return fmt.Sprintf("validation accuracy >= %g", float64(c))
+}
+
+// minGradientNorm stops when gradients vanish
+type minGradientNorm float64
+
+// MinGradientNorm stops the training when the mean gradient norm of the epoch
+// is lower or equal to the given value
+func MinGradientNorm(norm float64) Criterion {
+	return minGradientNorm(norm)
+}
+
+func (c minGradientNorm) Reached(history History) bool {
+	return len(history) > 0 && history.Last().GradientNorm <= float64(c)
+}
+
+func (c minGradientNorm) String() string {
+	return fmt.Sprintf("gradient norm <= %g", float64(c))
+}
+
+// maxNonFinite stops when too many losses are NaN or infinite
+type maxNonFinite int
+
+// MaxNonFinite stops the training when the total number of non finite losses
+// (NaN or infinite) exceeds the given value
+func MaxNonFinite(count int) Criterion {
+	return maxNonFinite(count)
+}
+
+func (c maxNonFinite) Reached(history History) bool {
+	return len(history) > 0 && history.Last().NonFinite > int(c)
+}
+
+func (c maxNonFinite) String() string {
+	return fmt.Sprintf("non finite losses > %d", int(c))
+}
+
+// predicate stops using a user function
+type predicate struct {
+	name string
+	fct  func(History) bool
+}
+
+// Predicate stops the training when the given function returns true
+func Predicate(name string, fct func(History) bool) Criterion {
+	return predicate{
+		name: name,
+		fct:  fct,
+	}
+}
+
+func (c predicate) Reached(history History) bool {
+	return c.fct(history)
+}
+
+func (c predicate) String() string {
+	return c.name
+}
+
+// and is reached if all criteria are reached
+type and []Criterion
+
+// And stops the training when all the given criteria are reached
+func And(criteria ...Criterion) Criterion {
+	return and(criteria)
+}
+
+func (c and) Reached(history History) bool {
+	for _, crit := range c {
+		if !crit.Reached(history) {
+			return false
+		}
+	}
+	return len(c) > 0
+}
+
+func (c and) String() string {
+	return "(" + joinCriteria(c, " and ") + ")"
+}
+
+// or is reached if at least one criterion is reached
+type or []Criterion
+
+// Or stops the training when at least one of the given criteria is reached
+func Or(criteria ...Criterion) Criterion {
+	return or(criteria)
+}
+
+func (c or) Reached(history History) bool {
+	return c.fired(history) != nil
+}
+
+// fired returns the first (deepest) criterion reached, or nil
+func (c or) fired(history History) Criterion {
+	for _, crit := range c {
+		if sub, ok := crit.(or); ok {
+			if fired := sub.fired(history); fired != nil {
+				return fired
+			}
+		} else if crit.Reached(history) {
+			return crit
+		}
+	}
+	return nil
+}
+
+func (c or) String() string {
+	return "(" + joinCriteria(c, " or ") + ")"
+}
+
+// not is reached if the criterion is not reached
+type not struct {
+	crit Criterion
+}
+
+// Not stops the training when the given criterion is not reached
+func Not(crit Criterion) Criterion {
+	return not{crit: crit}
+}
+
+func (c not) Reached(history History) bool {
+	return !c.crit.Reached(history)
+}
+
+func (c not) String() string {
+	return "not " + c.crit.String()
+}
+
+// joinCriteria converts a list of criteria to string
+func joinCriteria(criteria []Criterion, sep string) string {
+	str := make([]string, len(criteria))
+	for i, crit := range criteria {
+		str[i] = crit.String()
+	}
+	return strings.Join(str, sep)
+}
@@ -0,0 +1,133 @@
+package mlp
+
+import (
+	"fmt"
+	"io"
+)
+
+// Dataset provides samples that can be lazily loaded
+type Dataset interface {
+	Open() (Iterator, error) // Starts a new pass over all samples
+}
+
+// Iterator reads the samples of one pass
+type Iterator interface {
+	Next() (x, y []float64, err error) // Returns io.EOF when all samples are read
+	Close() error
+}
+
+// slices is an in-memory dataset
+type slices struct {
+	xData, yData [][]float64
+}
+
+// Slices builds a dataset of in-memory inputs and outputs
+func Slices(xData, yData [][]float64) Dataset {
+	return slices{
+		xData: xData,
+		yData: yData,
+	}
+}
+
+func (ds slices) Open() (Iterator, error) {
+	if len(ds.xData) != len(ds.yData) {
+		return nil, fmt.Errorf("input / output should have the same length")
+	}
+	return &slicesIterator{slices: ds}, nil
+}
+
+// slicesIterator reads in-memory samples
+type slicesIterator struct {
+	slices
+	i int
+}
+
+func (it *slicesIterator) Next() ([]float64, []float64, error) {
+	if it.i >= len(it.xData) {
+		return nil, nil, io.EOF
+	}
+	it.i++
+	return it.xData[it.i-1], it.yData[it.i-1], nil
+}
+
+func (it *slicesIterator) Close() error {
+	return nil
+}
+
+// prefetch reads samples in background
+type prefetch struct {
+	ds   Dataset
+	size int
+}
+
+// Prefetch wraps a dataset so that the next "size" samples are read in background,
+// reading input / output data while the network computes
+func Prefetch(ds Dataset, size int) Dataset {
+	return prefetch{
+		ds:   ds,
+		size: size,
+	}
+}
+
+// sample read in background
+type sample struct {
+	x, y []float64
+	err  error
+}
+
+func (ds prefetch) Open() (Iterator, error) {
+	it, err := ds.ds.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make(chan sample, ds.size)
+	done := make(chan struct{})
+	go func() {
+		defer close(samples)
+		for {
+			x, y, err := it.Next()
+			select {
+			case samples <- sample{x: x, y: y, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return &prefetchIterator{
+		it:      it,
+		samples: samples,
+		done:    done,
+	}, nil
+}
+
+// prefetchIterator reads samples from the background routine
+type prefetchIterator struct {
+	it      Iterator
+	samples chan sample
+	done    chan struct{}
+	err     error // last error (sticky)
+}
+
+func (it *prefetchIterator) Next() ([]float64, []float64, error) {
+	if it.err != nil {
+		return nil, nil, it.err
+	}
+	s, ok := <-it.samples
+	if !ok {
+		s.err = io.EOF
+	}
+	it.err = s.err
+	return s.x, s.y, s.err
+}
+
+func (it *prefetchIterator) Close() error {
+	close(it.done)
+	for range it.samples { // wait for the routine to stop
+	}
+	return it.it.Close()
+}
@@ -0,0 +1,137 @@
+package mlp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// readAll reads all samples of one pass
+func readAll(ds Dataset) ([][]float64, [][]float64, error) {
+	it, err := ds.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer it.Close()
+
+	var xData, yData [][]float64
+	for {
+		x, y, err := it.Next()
+		if err == io.EOF {
+			return xData, yData, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		xData = append(xData, x)
+		yData = append(yData, y)
+	}
+}
+
+// failing dataset returns an error after n samples
+type failing struct {
+	n int
+}
+
+func (ds failing) Open() (Iterator, error) {
+	return &failingIterator{n: ds.n}, nil
+}
+
+type failingIterator struct {
+	n, i   int
+	closed bool
+}
+
+func (it *failingIterator) Next() ([]float64, []float64, error) {
+	if it.i >= it.n {
+		return nil, nil, errors.New("read error")
+	}
+	it.i++
+	return []float64{float64(it.i), 0}, []float64{1}, nil
+}
+
+func (it *failingIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+func TestDataset(t *testing.T) {
+	Convey("dataset", t, func() {
+		xData := [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+		yData := [][]float64{{0}, {1}, {1}, {0}}
+
+		Convey("slices", func() {
+			x, y, err := readAll(Slices(xData, yData))
+			So(err, ShouldBeNil)
+			So(x, ShouldResemble, xData)
+			So(y, ShouldResemble, yData)
+
+			_, err = Slices(xData, yData[:1]).Open()
+			So(err, ShouldBeError, "input / output should have the same length")
+		})
+
+		Convey("prefetch", func() {
+			x, y, err := readAll(Prefetch(Slices(xData, yData), 2))
+			So(err, ShouldBeNil)
+			So(x, ShouldResemble, xData)
+			So(y, ShouldResemble, yData)
+
+			// Errors are sticky
+			it, err := Prefetch(failing{n: 1}, 0).Open()
+			So(err, ShouldBeNil)
+			_, _, err = it.Next()
+			So(err, ShouldBeNil)
+			_, _, err = it.Next()
+			So(err, ShouldBeError, "read error")
+			_, _, err = it.Next()
+			So(err, ShouldBeError, "read error")
+			So(it.Close(), ShouldBeNil)
+
+			// Close before the end stops the routine and closes the inner iterator
+			it, err = Prefetch(failing{n: 100}, 3).Open()
+			So(err, ShouldBeNil)
+			_, _, err = it.Next()
+			So(err, ShouldBeNil)
+			So(it.Close(), ShouldBeNil)
+			So(it.(*prefetchIterator).it.(*failingIterator).closed, ShouldBeTrue)
+		})
+
+		Convey("train and evaluate", func() {
+			net1 := NewNetwork(0.3, 2)
+			net1.AddLayer(LinearBuilder{}, 3, Sigmoid{})
+			net1.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+			net2 := Network{}
+			js, err := net1.MarshalJSON()
+			So(err, ShouldBeNil)
+			So(net2.UnmarshalJSON(js), ShouldBeNil)
+
+			// Same training using slices or a prefetched dataset
+			net1.Stop.OnEpoch(10)
+			net2.Stop.OnEpoch(10)
+			term1, err1 := net1.Train(context.Background(), xData, yData)
+			term2, err2 := net2.TrainDataset(context.Background(), Prefetch(Slices(xData, yData), 2))
+			So(err1, ShouldBeNil)
+			So(err2, ShouldBeNil)
+			So(term2.History().Last().MeanSquaredError, ShouldEqual, term1.History().Last().MeanSquaredError)
+
+			eval1, err1 := net1.Evaluate(xData, yData)
+			eval2, err2 := net2.EvaluateDataset(Prefetch(Slices(xData, yData), 2))
+			So(err1, ShouldBeNil)
+			So(err2, ShouldBeNil)
+			So(eval2, ShouldResemble, eval1)
+
+			// Errors
+			_, err = net2.TrainDataset(context.Background(), failing{n: 2})
+			So(err, ShouldBeError, "read error")
+			_, err = net2.EvaluateDataset(Slices(nil, nil))
+			So(err, ShouldBeError, "at least one sample expected")
+
+			So(net2.SetScaler(&Standardize{}), ShouldBeNil)
+			_, err = net2.TrainDataset(context.Background(), Slices(xData, yData))
+			So(err, ShouldBeError, `scaler "standardize" shall be fitted`)
+		})
+	})
+}
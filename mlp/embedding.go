@@ -0,0 +1,264 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// EmbeddingColumn describes a categorical input
+type EmbeddingColumn struct {
+	Position   int `json:"position"`   // Index of the input holding the category index
+	Categories int `json:"categories"` // Number of categories (indices from 0 to categories-1)
+	Dim        int `json:"dim"`        // Size of the learned vectors
+}
+
+// Embedding layer replaces categorical inputs (category indices) by learned dense vectors
+// y = [other inputs..., vector of each categorical input...]
+// Unknown categories (out of range indices) produce zero vectors.
+type Embedding struct {
+	in      int               // Number of inputs
+	columns []EmbeddingColumn // Categorical inputs
+	tables  []matrix          // Vectors of each column: d = categories x dim
+	grads   []matrix          // Gradients of the vectors: d = categories x dim
+	used    []map[int]bool    // Rows of each column used since the last update
+}
+
+// NewEmbedding allocates the vectors of each categorical input (drawn from the standard normal distribution)
+func NewEmbedding(in int, columns ...EmbeddingColumn) (Embedding, error) {
+	emb := Embedding{
+		in:      in,
+		columns: columns,
+		tables:  make([]matrix, len(columns)),
+	}
+	if err := emb.check(); err != nil {
+		return Embedding{}, err
+	}
+	for c, col := range columns {
+		table := newMatrix(col.Categories, col.Dim)
+		emb.tables[c] = table.iter(func(i, j int) {
+			table[i][j] = normRandom(1, 0)
+		})
+	}
+	return emb, emb.init()
+}
+
+// check the columns
+func (emb Embedding) check() error {
+	if len(emb.columns) == 0 {
+		return fmt.Errorf("at least one categorical input expected")
+	}
+	positions := map[int]bool{}
+	for _, col := range emb.columns {
+		switch {
+		case col.Position < 0 || col.Position >= emb.in:
+			return fmt.Errorf("position %d not found in %d inputs", col.Position, emb.in)
+		case positions[col.Position]:
+			return fmt.Errorf("position %d already embedded", col.Position)
+		case col.Categories <= 0 || col.Dim <= 0:
+			return fmt.Errorf("position %d: categories and dim shall be positive", col.Position)
+		}
+		positions[col.Position] = true
+	}
+	return nil
+}
+
+// init checks the columns and the vectors, then allocates the gradients
+func (emb *Embedding) init() error {
+	if err := emb.check(); err != nil {
+		return err
+	}
+	for c, col := range emb.columns {
+		if len(emb.tables[c]) != col.Categories || len(emb.tables[c][0]) != col.Dim {
+			return fmt.Errorf("position %d: vectors do not match %d categories x %d dim", col.Position, col.Categories, col.Dim)
+		}
+	}
+
+	emb.grads = make([]matrix, len(emb.columns))
+	emb.used = make([]map[int]bool, len(emb.columns))
+	for c, col := range emb.columns {
+		emb.grads[c] = newMatrix(col.Categories, col.Dim).zeros()
+		emb.used[c] = map[int]bool{}
+	}
+	return nil
+}
+
+// row converts an input to the row of the column (-1 if unknown category)
+func (emb Embedding) row(c int, x []float64) int {
+	row := math.Round(x[emb.columns[c].Position])
+	if row < 0 || row >= float64(emb.columns[c].Categories) {
+		return -1
+	}
+	return int(row)
+}
+
+// categorical checks if an input is embedded
+func (emb Embedding) categorical(i int) bool {
+	for _, col := range emb.columns {
+		if col.Position == i {
+			return true
+		}
+	}
+	return false
+}
+
+// FeedForward copies the other inputs then appends the vector of each category
+func (emb Embedding) FeedForward(x []float64) []float64 {
+	y := newVector(0)
+	for i, xi := range x {
+		if !emb.categorical(i) {
+			y = append(y, xi)
+		}
+	}
+	for c, col := range emb.columns {
+		vec := newVector(col.Dim).zeros()
+		if row := emb.row(c, x); row >= 0 {
+			copy(vec, emb.tables[c][row])
+		}
+		y = append(y, vec...)
+	}
+	return y
+}
+
+// BackPropagation computes the x gradient (0 for categorical inputs)
+// and accumulates the gradients of the used vectors only
+func (emb Embedding) BackPropagation(x, yGrad []float64) []float64 {
+	xGrad := newVector(len(x)).zeros()
+	var offset int
+	for i := range x {
+		if !emb.categorical(i) {
+			xGrad[i] = yGrad[offset]
+			offset++
+		}
+	}
+	for c, col := range emb.columns {
+		if row := emb.row(c, x); row >= 0 {
+			grad := emb.grads[c][row]
+			for j := range grad {
+				grad[j] += yGrad[offset+j]
+			}
+			emb.used[c][row] = true
+		}
+		offset += col.Dim
+	}
+	return xGrad
+}
+
+// Update the used vectors only
+func (emb Embedding) Update(learningRate float64) {
+	for c := range emb.columns {
+		for row := range emb.used[c] {
+			vec, grad := emb.tables[c][row], emb.grads[c][row]
+			for j := range vec {
+				vec[j] -= learningRate * grad[j]
+				grad[j] = 0
+			}
+			delete(emb.used[c], row)
+		}
+	}
+}
+
+// gradientNorm2 sums the squared gradients of the used vectors only
+func (emb Embedding) gradientNorm2() float64 {
+	var sum float64
+	for c := range emb.columns {
+		for row := range emb.used[c] {
+			for _, grad := range emb.grads[c][row] {
+				sum += grad * grad
+			}
+		}
+	}
+	return sum
+}
+
+// Parameters returns each vector of each column
+func (emb Embedding) Parameters() []Parameter {
+	var params []Parameter
+	for c, col := range emb.columns {
+		for row := range emb.tables[c] {
+			params = append(params, Parameter{
+				Name:   fmt.Sprintf("position[%d][%d]", col.Position, row),
+				Values: emb.tables[c][row],
+				Grads:  emb.grads[c][row],
+			})
+		}
+	}
+	return params
+}
+
+func (emb Embedding) Type() string {
+	return "embedding"
+}
+
+// Columns returns the categorical inputs
+func (emb Embedding) Columns() []EmbeddingColumn {
+	return append([]EmbeddingColumn(nil), emb.columns...)
+}
+
+// Vectors returns a copy of the vectors of a categorical input (categories x dim)
+func (emb Embedding) Vectors(position int) ([][]float64, error) {
+	for c, col := range emb.columns {
+		if col.Position == position {
+			vectors := make([][]float64, len(emb.tables[c]))
+			for row, vec := range emb.tables[c] {
+				vectors[row] = append([]float64(nil), vec...)
+			}
+			return vectors, nil
+		}
+	}
+	return nil, fmt.Errorf("position %d is not embedded", position)
+}
+
+// summary describes the layer
+func (emb Embedding) summary(in int) LayerSummary {
+	sum := LayerSummary{
+		Type:    emb.Type(),
+		Inputs:  in,
+		Outputs: in,
+	}
+	for _, col := range emb.columns {
+		sum.Outputs += col.Dim - 1
+		sum.Parameters += col.Categories * col.Dim
+	}
+	return sum
+}
+
+type exportEmbedding struct {
+	Inputs  int               `json:"inputs"`
+	Columns []EmbeddingColumn `json:"columns"`
+	Vectors []matrix          `json:"vectors"`
+}
+
+func (emb Embedding) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportEmbedding{
+		Inputs:  emb.in,
+		Columns: emb.columns,
+		Vectors: emb.tables,
+	})
+}
+
+func (emb *Embedding) UnmarshalJSON(data []byte) error {
+	exp := exportEmbedding{}
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+	if len(exp.Vectors) != len(exp.Columns) {
+		return fmt.Errorf("vectors (%d) do not match columns (%d)", len(exp.Vectors), len(exp.Columns))
+	}
+	for c, table := range exp.Vectors {
+		if len(table) == 0 {
+			return fmt.Errorf("position %d: no vector", exp.Columns[c].Position)
+		}
+		for _, vec := range table {
+			if len(vec) != len(table[0]) {
+				return fmt.Errorf("position %d: vectors of different sizes", exp.Columns[c].Position)
+			}
+		}
+	}
+
+	emb.in = exp.Inputs
+	emb.columns = exp.Columns
+	emb.tables = exp.Vectors
+	return emb.init()
+}
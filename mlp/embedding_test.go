@@ -0,0 +1,115 @@
+package mlp
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEmbedding(t *testing.T) {
+	Convey("embedding", t, func() {
+		rand.Seed(42)
+		// inputs: age, country (3 categories), size, user (5 users)
+		emb, err := NewEmbedding(4,
+			EmbeddingColumn{Position: 1, Categories: 3, Dim: 2},
+			EmbeddingColumn{Position: 3, Categories: 5, Dim: 3},
+		)
+		So(err, ShouldBeNil)
+		x := []float64{0.5, 2, -0.3, 4}
+
+		Convey("feed forward", func() {
+			countries, err := emb.Vectors(1)
+			So(err, ShouldBeNil)
+			users, err := emb.Vectors(3)
+			So(err, ShouldBeNil)
+
+			y := emb.FeedForward(x)
+			So(y, ShouldHaveLength, 2+2+3)
+			So(y[:2], ShouldResemble, []float64{0.5, -0.3})
+			So(y[2:4], ShouldResemble, countries[2])
+			So(y[4:], ShouldResemble, users[4])
+
+			// Unknown categories
+			So(emb.FeedForward([]float64{0.5, -1, -0.3, 5})[2:], ShouldResemble, []float64{0, 0, 0, 0, 0})
+			So(emb.summary(4), ShouldResemble, LayerSummary{Type: "embedding", Inputs: 4, Outputs: 7, Parameters: 21})
+		})
+
+		Convey("sparse updates", func() {
+			before, _ := emb.Vectors(3)
+			xGrad := emb.BackPropagation(x, []float64{1, 2, 3, 4, 5, 6, 7})
+			So(xGrad, ShouldResemble, []float64{1, 0, 2, 0})
+			So(emb.used[1], ShouldResemble, map[int]bool{4: true})
+			So(gradientNorm2(emb), ShouldEqual, 3*3+4*4+5*5+6*6+7*7) // used vectors only
+
+			emb.Update(0.1)
+			after, _ := emb.Vectors(3)
+			So(after[:4], ShouldResemble, before[:4])
+			So(after[4][0], ShouldAlmostEqual, before[4][0]-0.5)
+			So(emb.used[1], ShouldBeEmpty)
+			So(emb.grads[1][4], ShouldResemble, []float64{0, 0, 0})
+			So(gradientNorm2(emb), ShouldEqual, 0)
+		})
+
+		Convey("gradients", func() {
+			result, err := GradCheck(x, []float64{1, 0, 0, 1, 0, 1, 0}, 1e-6, emb, NewActivatorLayer(Sigmoid{}))
+			So(err, ShouldBeNil)
+			So(result.MaxError().Error, ShouldBeLessThan, 1e-6)
+			So(result.Parameters, ShouldHaveLength, 21)
+		})
+
+		Convey("network", func() {
+			net := NewNetwork(0.5, 4)
+			So(net.Add(emb), ShouldBeNil)
+			net.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+			So(net.Neurons(), ShouldResemble, []int{4, 7, 1})
+			So(net.Add(NewLinear(2, 1)), ShouldBeError, "linear layer inputs (2) do not match 1 values")
+
+			// Each user has its own target
+			xData := [][]float64{{0, 0, 0, 0}, {0, 0, 0, 1}, {0, 0, 0, 2}, {0, 0, 0, 3}}
+			yData := [][]float64{{1}, {0}, {1}, {0}}
+			unused, _ := emb.Vectors(3)
+			net.Stop.OnEpoch(300)
+			_, err := net.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+			eval, err := net.Evaluate(xData, yData)
+			So(err, ShouldBeNil)
+			So(eval.Accuracy, ShouldEqual, 1)
+
+			// User 4 is never used
+			users, _ := emb.Vectors(3)
+			So(users[4], ShouldResemble, unused[4])
+			So(users[0], ShouldNotResemble, unused[0])
+
+			data, err := json.Marshal(net)
+			So(err, ShouldBeNil)
+			var net2 Network
+			So(json.Unmarshal(data, &net2), ShouldBeNil)
+			So(net2.layers[0], ShouldResemble, net.layers[0])
+			So(net2.Predict(x), ShouldResemble, net.Predict(x))
+		})
+
+		Convey("errors", func() {
+			_, err := NewEmbedding(4)
+			So(err, ShouldBeError, "at least one categorical input expected")
+			_, err = NewEmbedding(4, EmbeddingColumn{Position: 4, Categories: 1, Dim: 1})
+			So(err, ShouldBeError, "position 4 not found in 4 inputs")
+			_, err = NewEmbedding(4, EmbeddingColumn{Position: 1, Categories: 1, Dim: 1}, EmbeddingColumn{Position: 1, Categories: 1, Dim: 1})
+			So(err, ShouldBeError, "position 1 already embedded")
+			_, err = NewEmbedding(4, EmbeddingColumn{Position: 1, Categories: 0, Dim: 1})
+			So(err, ShouldBeError, "position 1: categories and dim shall be positive")
+			_, err = NewEmbedding(4, EmbeddingColumn{Position: 1, Categories: -1, Dim: 1})
+			So(err, ShouldBeError, "position 1: categories and dim shall be positive")
+			_, err = NewEmbedding(4, EmbeddingColumn{Position: 1, Categories: 2, Dim: -1})
+			So(err, ShouldBeError, "position 1: categories and dim shall be positive")
+			_, err = emb.Vectors(0)
+			So(err, ShouldBeError, "position 0 is not embedded")
+
+			var emb2 Embedding
+			err = emb2.UnmarshalJSON([]byte(`{"inputs":2,"columns":[{"position":0,"categories":2,"dim":1}],"vectors":[[[1],[2],[3]]]}`))
+			So(err, ShouldBeError, "position 0: vectors do not match 2 categories x 1 dim")
+		})
+	})
+}
@@ -0,0 +1,77 @@
+package mlp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Freeze marks layers (index of Layers) as non trainable: their parameters are not updated,
+// but gradients still flow through them to the previous layers
+func (net *Network) Freeze(indexes ...int) error {
+	for _, i := range indexes {
+		if i < 0 || i >= len(net.layers) {
+			return fmt.Errorf("layer %d not found in %d layers", i, len(net.layers))
+		}
+	}
+	for _, i := range indexes {
+		if net.frozen == nil {
+			net.frozen = map[int]bool{}
+		}
+		net.frozen[i] = true
+	}
+	return nil
+}
+
+// Unfreeze marks layers (index of Layers) as trainable again
+func (net *Network) Unfreeze(indexes ...int) {
+	for _, i := range indexes {
+		delete(net.frozen, i)
+	}
+	if len(net.frozen) == 0 {
+		net.frozen = nil
+	}
+}
+
+// Frozen checks if a layer (index of Layers) is not trainable
+func (net Network) Frozen(i int) bool {
+	return net.frozen[i]
+}
+
+// frozenIndexes lists the frozen layers in ascending order
+func (net Network) frozenIndexes() []int {
+	var indexes []int
+	for i := range net.frozen {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// Cut keeps the first n layers (see Layers) of the network, so that new layers can be added on top
+// of the learnt features. A layer shall be followed by its activator to keep the network consistent.
+func (net *Network) Cut(n int) error {
+	if n <= 0 || n > len(net.layers) {
+		return fmt.Errorf("cannot keep %d layers of %d", n, len(net.layers))
+	}
+
+	net.layers = append([]Layer(nil), net.layers[:n]...)
+	for i := range net.frozen {
+		if i >= n {
+			delete(net.frozen, i)
+		}
+	}
+	if len(net.frozen) == 0 {
+		net.frozen = nil
+	}
+
+	// Rebuild the number of neurons of the kept layers
+	sum := net.Summary()
+	neurons := []int{net.in()}
+	for i, layer := range net.layers {
+		if _, ok := layer.(summarizer); ok {
+			neurons = append(neurons, sum.Layers[i].Outputs)
+		}
+	}
+	net.neurons = neurons
+	return nil
+}
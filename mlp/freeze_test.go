@@ -0,0 +1,81 @@
+package mlp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFreeze(t *testing.T) {
+	Convey("freeze", t, func() {
+		net := NewNetwork(0.5, 2)
+		net.AddLayer(LinearBuilder{}, 4, Htan{})
+		net.AddLayer(LinearBuilder{}, 3, Htan{})
+		net.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+		xData := [][]float64{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+		yData := [][]float64{{0}, {1}, {1}, {0}}
+
+		Convey("cut, stack and train new layers only", func() {
+			So(net.Cut(4), ShouldBeNil)
+			So(net.Neurons(), ShouldResemble, []int{2, 4, 3})
+			So(net.Freeze(0, 2), ShouldBeNil)
+			So(net.Frozen(0), ShouldBeTrue)
+			So(net.Frozen(1), ShouldBeFalse)
+			net.AddLayer(LinearBuilder{}, 2, Sigmoid{})
+			So(net.Neurons(), ShouldResemble, []int{2, 4, 3, 2})
+
+			frozen := net.layers[2].(Linear).Weights()
+			added := net.layers[4].(Linear).Weights()
+			net.Stop.OnEpoch(5)
+			_, err := net.Train(context.Background(), xData, [][]float64{{0, 1}, {1, 0}, {1, 0}, {0, 1}})
+			So(err, ShouldBeNil)
+			So(net.layers[2].(Linear).Weights(), ShouldResemble, frozen)
+			So(net.layers[2].(Linear).weightsGrad[0][0], ShouldEqual, 0)
+			So(net.layers[4].(Linear).Weights(), ShouldNotResemble, added)
+
+			sum := net.Summary()
+			So(sum.Layers[0].Frozen, ShouldBeTrue)
+			So(sum.Parameters, ShouldEqual, 12+15+8)
+			So(sum.Trainable, ShouldEqual, 8)
+			So(sum.String(), ShouldContainSubstring, "trainable parameters: 8\n")
+
+			net.Unfreeze(0, 2)
+			So(net.frozen, ShouldBeNil)
+			So(net.Summary().Trainable, ShouldEqual, 35)
+		})
+
+		Convey("gradients still flow through frozen layers", func() {
+			So(net.Freeze(2), ShouldBeNil)
+			first := net.layers[0].(Linear).Weights()
+			net.Stop.OnEpoch(2)
+			_, err := net.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+			So(net.layers[0].(Linear).Weights(), ShouldNotResemble, first)
+		})
+
+		Convey("json", func() {
+			So(net.Freeze(2, 0), ShouldBeNil)
+			data, err := json.Marshal(net)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, `"frozen":[0,2]`)
+
+			var net2 Network
+			So(json.Unmarshal(data, &net2), ShouldBeNil)
+			So(net2.frozen, ShouldResemble, map[int]bool{0: true, 2: true})
+		})
+
+		Convey("errors", func() {
+			So(net.Freeze(6), ShouldBeError, "layer 6 not found in 6 layers")
+			So(net.Freeze(-1), ShouldBeError, "layer -1 not found in 6 layers")
+			So(net.frozen, ShouldBeNil)
+			So(net.Cut(0), ShouldBeError, "cannot keep 0 layers of 6")
+			So(net.Cut(7), ShouldBeError, "cannot keep 7 layers of 6")
+
+			So(net.Freeze(4), ShouldBeNil)
+			So(net.Cut(2), ShouldBeNil)
+			So(net.frozen, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package mlp
+
+import (
+	"fmt"
+	"math"
+)
+
+// GradError compares the analytic and the numeric gradient of one value
+type GradError struct {
+	Name     string  // Parameter (or input) name and index
+	Analytic float64 // Gradient computed by BackPropagation
+	Numeric  float64 // Gradient computed by central finite differences
+	Error    float64 // Relative error |a - n| / max(|a| + |n|, 1e-8)
+}
+
+func (e GradError) String() string {
+	return fmt.Sprintf("%s: analytic %g, numeric %g, error %.2e", e.Name, e.Analytic, e.Numeric, e.Error)
+}
+
+// GradCheckResult lists the gradient errors of each input and parameter value
+type GradCheckResult struct {
+	Inputs     []GradError
+	Parameters []GradError
+}
+
+// MaxError returns the worst gradient error (nil if no gradient is checked)
+func (r GradCheckResult) MaxError() *GradError {
+	var worst *GradError
+	for _, errors := range [][]GradError{r.Inputs, r.Parameters} {
+		for i := range errors {
+			if worst == nil || errors[i].Error > worst.Error {
+				worst = &errors[i]
+			}
+		}
+	}
+	return worst
+}
+
+// GradCheck compares the gradients computed by BackPropagation with central finite differences
+// on the loss 0.5 * ||y - target||² (whose gradient is y - target, as during the training).
+// Layers are chained: use net.Layers() to check a whole network.
+// Weights penalties (see Regularization) are not part of the loss, they are applied by Update.
+// Accumulated gradients of the layers are cleared.
+func GradCheck(x, target []float64, eps float64, layers ...Layer) (GradCheckResult, error) {
+	if len(layers) == 0 {
+		return GradCheckResult{}, fmt.Errorf("at least one layer expected")
+	}
+
+	// Analytic gradients
+	clearGrads(layers)
+	inputs := make([][]float64, len(layers))
+	y := x
+	for i, layer := range layers {
+		inputs[i] = y
+		y = layer.FeedForward(y)
+	}
+	if len(y) != len(target) {
+		return GradCheckResult{}, fmt.Errorf("output (%d) does not match target (%d)", len(y), len(target))
+	}
+	grad := newVector(len(y))
+	grad.iter(func(j int) {
+		grad[j] = y[j] - target[j]
+	})
+	for i := len(layers) - 1; i >= 0; i-- {
+		grad = layers[i].BackPropagation(inputs[i], grad)
+	}
+
+	// Numeric gradient of one value: (loss(v + eps) - loss(v - eps)) / 2 eps
+	loss := func() float64 {
+		y := x
+		for _, layer := range layers {
+			y = layer.FeedForward(y)
+		}
+		var sum float64
+		for j := range y {
+			sum += (y[j] - target[j]) * (y[j] - target[j])
+		}
+		return sum / 2
+	}
+	numeric := func(v *float64) float64 {
+		saved := *v
+		*v = saved + eps
+		plus := loss()
+		*v = saved - eps
+		minus := loss()
+		*v = saved
+		return (plus - minus) / (2 * eps)
+	}
+
+	var result GradCheckResult
+	x = append([]float64(nil), x...) // do not modify the caller input
+	for j := range x {
+		result.Inputs = append(result.Inputs, newGradError(fmt.Sprintf("input[%d]", j), grad[j], numeric(&x[j])))
+	}
+	for i, layer := range layers {
+		trainable, ok := layer.(Trainable)
+		if !ok {
+			continue
+		}
+		for _, param := range trainable.Parameters() {
+			for j := range param.Values {
+				name := fmt.Sprintf("layer %d %s[%d]", i, param.Name, j)
+				result.Parameters = append(result.Parameters, newGradError(name, param.Grads[j], numeric(&param.Values[j])))
+			}
+		}
+	}
+	clearGrads(layers)
+	return result, nil
+}
+
+// newGradError computes the relative error
+func newGradError(name string, analytic, numeric float64) GradError {
+	return GradError{
+		Name:     name,
+		Analytic: analytic,
+		Numeric:  numeric,
+		Error:    math.Abs(analytic-numeric) / math.Max(math.Abs(analytic)+math.Abs(numeric), 1e-8),
+	}
+}
+
+// clearGrads resets the accumulated gradients of the layers
+func clearGrads(layers []Layer) {
+	for _, layer := range layers {
+		if trainable, ok := layer.(Trainable); ok {
+			for _, param := range trainable.Parameters() {
+				for j := range param.Grads {
+					param.Grads[j] = 0
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package mlp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// wrongSigmoid has a wrong derivative
+type wrongSigmoid struct {
+	Sigmoid
+}
+
+func (s wrongSigmoid) Deriv(x float64) float64 {
+	return 2 * s.Sigmoid.Deriv(x)
+}
+
+func TestGradCheck(t *testing.T) {
+	Convey("gradient check", t, func() {
+		rand.Seed(42)
+		const eps, tolerance = 1e-6, 1e-6
+		x := []float64{0.5, -0.3, 0.8}
+		target := []float64{0.1, 0.9}
+
+		// checkGrads checks the worst gradient error
+		checkGrads := func(layers ...Layer) GradCheckResult {
+			result, err := GradCheck(x, target, eps, layers...)
+			So(err, ShouldBeNil)
+			So(result.MaxError(), ShouldNotBeNil)
+			So(result.MaxError().Error, ShouldBeLessThan, tolerance)
+			return result
+		}
+
+		Convey("linear", func() {
+			ln := NewLinear(3, 2)
+			result := checkGrads(ln)
+			So(result.Inputs, ShouldHaveLength, 3)
+			So(result.Parameters, ShouldHaveLength, 2+3*2)
+			So(result.Parameters[0].Name, ShouldEqual, "layer 0 biases[0]")
+
+			// Gradients are cleared, input is not modified
+			So(ln.biaisesGrad, ShouldResemble, vector{0, 0})
+			So(x, ShouldResemble, []float64{0.5, -0.3, 0.8})
+
+			// The weights penalty is not part of the checked loss, it is applied by Update:
+			// with null gradients, each weight moves by the numeric gradient of the penalty
+			reg := Regularization{L1: 0.1, L2: 0.1}
+			ln = LinearBuilder{Initializer: Xavier{}, Regularization: reg}.New(3, 2).(Linear)
+			checkGrads(ln)
+			penalty := func(w float64) float64 {
+				return reg.L1*math.Abs(w) + reg.L2*w*w/2
+			}
+			weights := newMatrix(3, 2)
+			weights.iter(func(i, j int) { weights[i][j] = ln.weights[i][j] })
+			ln.Update(1)
+			weights.iter(func(i, j int) {
+				w := weights[i][j]
+				numeric := (penalty(w+eps) - penalty(w-eps)) / (2 * eps)
+				So(w-ln.weights[i][j], ShouldAlmostEqual, numeric, tolerance)
+			})
+		})
+
+		Convey("activators", func() {
+			for _, act := range []Activator{Sigmoid{}, Htan{}, ReLU{}} {
+				Convey(act.String(), func() {
+					result, err := GradCheck(x, []float64{0.1, 0.9, 0.2}, eps, NewActivatorLayer(act))
+					So(err, ShouldBeNil)
+					So(result.Parameters, ShouldBeEmpty)
+					So(result.MaxError().Error, ShouldBeLessThan, tolerance)
+				})
+			}
+		})
+
+		Convey("network", func() {
+			net := NewNetwork(0.3, 3)
+			net.AddLayer(LinearBuilder{}, 4, Htan{})
+			net.AddLayer(LinearBuilder{}, 3, ReLU{})
+			net.AddLayer(LinearBuilder{}, 2, Sigmoid{})
+			result := checkGrads(net.Layers()...)
+			So(result.Parameters, ShouldHaveLength, (3*4+4)+(4*3+3)+(3*2+2))
+		})
+
+		Convey("wrong derivative", func() {
+			result, err := GradCheck(x, target, eps, NewLinear(3, 2), NewActivatorLayer(wrongSigmoid{}))
+			So(err, ShouldBeNil)
+			So(result.MaxError().Error, ShouldBeGreaterThan, 0.1)
+		})
+
+		Convey("errors", func() {
+			_, err := GradCheck(x, target, eps)
+			So(err, ShouldBeError, "at least one layer expected")
+			_, err = GradCheck(x, target, eps, NewLinear(3, 1))
+			So(err, ShouldBeError, "output (1) does not match target (2)")
+		})
+	})
+}
@@ -0,0 +1,598 @@
+package mlp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Kinds of graph nodes
+const (
+	nodeInput    = "input"    // Values given to the graph
+	nodeSequence = "sequence" // Layers applied in sequence to one node
+	nodeConcat   = "concat"   // Concatenation of several nodes
+	nodeSum      = "sum"      // Element-wise sum of several nodes
+	nodeMultiply = "multiply" // Element-wise product of several nodes
+)
+
+// graphNode is a named step of the graph
+type graphNode struct {
+	name   string
+	kind   string
+	inputs []string // Names of the input nodes
+	layers []Layer  // Layers applied in sequence (layers nodes only)
+	size   int      // Number of output values
+}
+
+// Graph is a model whose nodes form a directed acyclic graph:
+// it supports multiple inputs and outputs, branches, skip connections and merge nodes.
+//
+// The inputs (resp. outputs) of a training or evaluation sample are concatenated
+// in the order of declaration of the input (resp. output) nodes.
+type Graph struct {
+	learningRate float64
+	nodes        []graphNode    // Nodes in topological order
+	index        map[string]int // Position of each node
+	inputs       []string       // Input nodes
+	outputs      []string       // Output nodes
+	validation   Dataset        // Validation data
+
+	Stop Termination // Ending conditions
+}
+
+// graphPass holds the values computed by a forward pass
+type graphPass struct {
+	values      [][]float64   // Output of each node
+	layerInputs [][][]float64 // Input of each layer of each node
+}
+
+// NewGraph builds an empty graph
+func NewGraph(learningRate float64) Graph {
+	return Graph{
+		learningRate: learningRate,
+		index:        map[string]int{},
+	}
+}
+
+// LearningRate returns the learning rate of the graph
+func (g Graph) LearningRate() float64 {
+	return g.learningRate
+}
+
+// Inputs returns the names of the input nodes
+func (g Graph) Inputs() []string {
+	return append([]string(nil), g.inputs...)
+}
+
+// Outputs returns the names of the output nodes
+func (g Graph) Outputs() []string {
+	return append([]string(nil), g.outputs...)
+}
+
+// Size returns the number of values produced by a node
+func (g Graph) Size(name string) (int, error) {
+	i, ok := g.index[name]
+	if !ok {
+		return 0, fmt.Errorf("node %q not found", name)
+	}
+	return g.nodes[i].size, nil
+}
+
+// Input adds an input node of a given size
+func (g *Graph) Input(name string, size int) error {
+	err := g.addNode(graphNode{name: name, kind: nodeInput, size: size})
+	if err == nil {
+		g.inputs = append(g.inputs, name)
+	}
+	return err
+}
+
+// Sequence adds a node applying the layers in sequence on an existing node
+func (g *Graph) Sequence(name, input string, layers ...Layer) error {
+	return g.addNode(graphNode{name: name, kind: nodeSequence, inputs: []string{input}, layers: layers})
+}
+
+// Dense adds a node applying a new layer and its activator on an existing node
+func (g *Graph) Dense(name, input string, bld LayerBuilder, neurons int, act Activator) error {
+	in, err := g.Size(input)
+	if err != nil {
+		return err
+	}
+	return g.Sequence(name, input, bld.New(in, neurons), NewActivatorLayer(act))
+}
+
+// Concat adds a node concatenating the values of existing nodes
+func (g *Graph) Concat(name string, inputs ...string) error {
+	return g.addNode(graphNode{name: name, kind: nodeConcat, inputs: inputs})
+}
+
+// Sum adds a node summing the values of existing nodes of the same size
+func (g *Graph) Sum(name string, inputs ...string) error {
+	return g.addNode(graphNode{name: name, kind: nodeSum, inputs: inputs})
+}
+
+// Multiply adds a node multiplying element-wise the values of existing nodes of the same size
+func (g *Graph) Multiply(name string, inputs ...string) error {
+	return g.addNode(graphNode{name: name, kind: nodeMultiply, inputs: inputs})
+}
+
+// Output marks existing nodes as outputs of the graph
+func (g *Graph) Output(names ...string) error {
+	for _, name := range names {
+		if _, ok := g.index[name]; !ok {
+			return fmt.Errorf("node %q not found", name)
+		}
+		for _, output := range g.outputs {
+			if output == name {
+				return fmt.Errorf("node %q is already an output", name)
+			}
+		}
+		g.outputs = append(g.outputs, name)
+	}
+	return nil
+}
+
+// addNode checks and appends a node whose inputs are already in the graph
+func (g *Graph) addNode(node graphNode) error {
+	if node.name == "" {
+		return fmt.Errorf("node name expected")
+	}
+	if _, ok := g.index[node.name]; ok {
+		return fmt.Errorf("node %q already exists", node.name)
+	}
+	size, err := g.nodeSize(node)
+	if err != nil {
+		return fmt.Errorf("node %q: %w", node.name, err)
+	}
+	node.size = size
+
+	if g.index == nil {
+		g.index = map[string]int{}
+	}
+	g.index[node.name] = len(g.nodes)
+	g.nodes = append(g.nodes, node)
+	return nil
+}
+
+// nodeSize computes the number of output values of a node
+func (g Graph) nodeSize(node graphNode) (int, error) {
+	sizes := make([]int, len(node.inputs))
+	for i, input := range node.inputs {
+		size, err := g.Size(input)
+		if err != nil {
+			return 0, err
+		}
+		sizes[i] = size
+	}
+
+	switch node.kind {
+	case nodeInput:
+		if node.size <= 0 {
+			return 0, fmt.Errorf("positive size expected")
+		}
+		return node.size, nil
+
+	case nodeSequence:
+		if len(sizes) != 1 {
+			return 0, fmt.Errorf("one input expected")
+		}
+		if len(node.layers) == 0 {
+			return 0, fmt.Errorf("at least one layer expected")
+		}
+		return outputSize(sizes[0], node.layers)
+
+	case nodeConcat:
+		if len(sizes) == 0 {
+			return 0, fmt.Errorf("at least one input expected")
+		}
+		var sum int
+		for _, size := range sizes {
+			sum += size
+		}
+		return sum, nil
+
+	case nodeSum, nodeMultiply:
+		if len(sizes) < 2 {
+			return 0, fmt.Errorf("at least two inputs expected")
+		}
+		for i, size := range sizes {
+			if size != sizes[0] {
+				return 0, fmt.Errorf("input %q (%d) does not match input %q (%d)", node.inputs[i], size, node.inputs[0], sizes[0])
+			}
+		}
+		return sizes[0], nil
+
+	default:
+		return 0, fmt.Errorf("unknown node type %q", node.kind)
+	}
+}
+
+// forward computes the values of all nodes in topological order
+func (g Graph) forward(x [][]float64) graphPass {
+	pass := graphPass{
+		values:      make([][]float64, len(g.nodes)),
+		layerInputs: make([][][]float64, len(g.nodes)),
+	}
+	for k, input := range g.inputs {
+		pass.values[g.index[input]] = x[k]
+	}
+	for i, node := range g.nodes {
+		ins := make([][]float64, len(node.inputs))
+		for j, input := range node.inputs {
+			ins[j] = pass.values[g.index[input]]
+		}
+
+		switch node.kind {
+		case nodeSequence:
+			io := ins[0]
+			pass.layerInputs[i] = make([][]float64, len(node.layers))
+			for j, layer := range node.layers {
+				pass.layerInputs[i][j] = io
+				io = layer.FeedForward(io)
+			}
+			pass.values[i] = io
+		case nodeConcat:
+			y := newVector(0)
+			for _, in := range ins {
+				y = append(y, in...)
+			}
+			pass.values[i] = y
+		case nodeSum:
+			y := newVector(node.size).zeros()
+			for _, in := range ins {
+				y.iter(func(j int) { y[j] += in[j] })
+			}
+			pass.values[i] = y
+		case nodeMultiply:
+			y := append(newVector(0), ins[0]...)
+			for _, in := range ins[1:] {
+				y.iter(func(j int) { y[j] *= in[j] })
+			}
+			pass.values[i] = y
+		}
+	}
+	return pass
+}
+
+// backPropagation computes the gradients of the layers (backward, in reverse topological order)
+func (g Graph) backPropagation(pass graphPass, yGrads [][]float64) {
+	grads := make([]vector, len(g.nodes))
+	accumulate := func(name string, grad vector) {
+		i := g.index[name]
+		if grads[i] == nil {
+			grads[i] = newVector(len(grad)).zeros()
+		}
+		grads[i].iter(func(j int) { grads[i][j] += grad[j] })
+	}
+	for k, output := range g.outputs {
+		accumulate(output, yGrads[k])
+	}
+
+	for i := len(g.nodes) - 1; i >= 0; i-- {
+		node, grad := g.nodes[i], grads[i]
+		if grad == nil { // not connected to the outputs
+			continue
+		}
+
+		switch node.kind {
+		case nodeSequence:
+			for j := len(node.layers) - 1; j >= 0; j-- {
+				grad = node.layers[j].BackPropagation(pass.layerInputs[i][j], grad)
+			}
+			accumulate(node.inputs[0], grad)
+		case nodeConcat:
+			var offset int
+			for _, input := range node.inputs {
+				size := g.nodes[g.index[input]].size
+				accumulate(input, grad[offset:offset+size])
+				offset += size
+			}
+		case nodeSum:
+			for _, input := range node.inputs {
+				accumulate(input, grad)
+			}
+		case nodeMultiply:
+			for a, input := range node.inputs {
+				xGrad := append(newVector(0), grad...)
+				for b, other := range node.inputs {
+					if a != b {
+						values := pass.values[g.index[other]]
+						xGrad.iter(func(j int) { xGrad[j] *= values[j] })
+					}
+				}
+				accumulate(input, xGrad)
+			}
+		}
+	}
+}
+
+// layers lists the layers of all nodes
+func (g Graph) layers() []Layer {
+	var layers []Layer
+	for _, node := range g.nodes {
+		layers = append(layers, node.layers...)
+	}
+	return layers
+}
+
+// update all layers
+func (g Graph) update() {
+	for _, layer := range g.layers() {
+		layer.Update(g.learningRate)
+	}
+}
+
+// gradientNorm computes the euclidean norm of all accumulated gradients
+func (g Graph) gradientNorm() float64 {
+	var sum float64
+	for _, layer := range g.layers() {
+		if trainable, ok := layer.(Trainable); ok {
+			sum += gradientNorm2(trainable)
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+// split cuts concatenated values into the values of each node
+func (g Graph) split(x []float64, names []string) ([][]float64, error) {
+	parts := make([][]float64, len(names))
+	var offset int
+	for i, name := range names {
+		size := g.nodes[g.index[name]].size
+		if offset+size > len(x) {
+			return nil, fmt.Errorf("data (%d) does not match nodes %v", len(x), names)
+		}
+		parts[i] = x[offset : offset+size]
+		offset += size
+	}
+	if offset != len(x) {
+		return nil, fmt.Errorf("data (%d) does not match nodes %v", len(x), names)
+	}
+	return parts, nil
+}
+
+// check that the graph can be trained on a sample and split it
+func (g Graph) check(x, y []float64) ([][]float64, [][]float64, error) {
+	switch {
+	case len(g.inputs) == 0:
+		return nil, nil, fmt.Errorf("at least one input expected")
+	case len(g.outputs) == 0:
+		return nil, nil, fmt.Errorf("at least one output expected")
+	}
+	xs, err := g.split(x, g.inputs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("input %w", err)
+	}
+	ys, err := g.split(y, g.outputs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("output %w", err)
+	}
+	return xs, ys, nil
+}
+
+// Predict computes the values of each output node from the values of each input node
+// (in the order of declaration). It is safe for concurrent use.
+// x shall hold one vector per input node, of the size of the node (see Size), otherwise Predict panics.
+func (g Graph) Predict(x ...[]float64) [][]float64 {
+	pass := g.forward(x)
+	y := make([][]float64, len(g.outputs))
+	for k, output := range g.outputs {
+		y[k] = pass.values[g.index[output]]
+	}
+	return y
+}
+
+// SetValidation sets the data evaluated at the end of each epoch
+func (g *Graph) SetValidation(xData, yData [][]float64) {
+	g.SetValidationDataset(Slices(xData, yData))
+}
+
+// SetValidationDataset sets the dataset evaluated at the end of each epoch
+func (g *Graph) SetValidationDataset(ds Dataset) {
+	g.validation = ds
+}
+
+// Train the graph until one of the stop criteria is reached
+func (g Graph) Train(ctx context.Context, xData, yData [][]float64) (Termination, error) {
+	return g.TrainDataset(ctx, Slices(xData, yData))
+}
+
+// TrainDataset trains the graph on lazily loaded samples until one of the stop criteria is reached
+func (g Graph) TrainDataset(ctx context.Context, ds Dataset) (Termination, error) {
+	return trainLoop{
+		model:        g,
+		learningRate: g.learningRate,
+		validation:   g.validation,
+		stop:         g.Stop,
+	}.run(ctx, ds)
+}
+
+// step computes the outputs of a training sample (concatenated) and accumulates the gradients
+func (g Graph) step(x, y []float64) ([]float64, error) {
+	xs, ys, err := g.check(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	pass := g.forward(xs)
+	out := newVector(0)
+	yGrads := make([][]float64, len(g.outputs))
+	for k, output := range g.outputs {
+		yk := pass.values[g.index[output]]
+		yGrad := newVector(len(yk)) // Gradient = y - target
+		yGrads[k] = yGrad.iter(func(j int) {
+			yGrad[j] = yk[j] - ys[k][j]
+		})
+		out = append(out, yk...)
+	}
+	g.backPropagation(pass, yGrads) // Compute gradients
+	return out, nil
+}
+
+// Evaluate computes the error and the accuracy of the graph on the given data.
+// The accuracy is the ratio of well classified outputs (see Network.Evaluate)
+func (g Graph) Evaluate(xData, yData [][]float64) (Evaluation, error) {
+	return g.EvaluateDataset(Slices(xData, yData))
+}
+
+// EvaluateDataset computes the error and the accuracy of the graph on lazily loaded samples.
+func (g Graph) EvaluateDataset(ds Dataset) (Evaluation, error) {
+	return evaluate(g, ds)
+}
+
+// score computes the squared error of a sample and its ratio of well classified outputs
+func (g Graph) score(x, y []float64) (float64, float64, error) {
+	xs, ys, err := g.check(x, y)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	out := newVector(0)
+	var accuracy float64
+	for k, yk := range g.Predict(xs...) {
+		if classify(yk) == classify(ys[k]) {
+			accuracy++
+		}
+		out = append(out, yk...)
+	}
+	return meanSquaredError(out, y), accuracy / float64(len(g.outputs)), nil
+}
+
+type exportGraphNode struct {
+	Name   string             `json:"name"`
+	Type   string             `json:"type"`
+	Inputs []string           `json:"inputs,omitempty"`
+	Size   int                `json:"size,omitempty"` // input nodes only
+	Layers []map[string]Layer `json:"layers,omitempty"`
+}
+
+// MarshalJSON exports the whole graph in a json format
+func (g Graph) MarshalJSON() ([]byte, error) {
+	nodes := make([]exportGraphNode, len(g.nodes))
+	for i, node := range g.nodes {
+		nodes[i] = exportGraphNode{
+			Name:   node.name,
+			Type:   node.kind,
+			Inputs: node.inputs,
+			Layers: marshalLayers(node.layers),
+		}
+		if node.kind == nodeInput {
+			nodes[i].Size = node.size
+		}
+	}
+
+	type marshal struct {
+		Rate    float64           `json:"learning-rate"`
+		Inputs  []string          `json:"inputs"`
+		Outputs []string          `json:"outputs"`
+		Nodes   []exportGraphNode `json:"nodes"`
+	}
+	return json.Marshal(marshal{
+		Rate:    g.learningRate,
+		Inputs:  g.inputs,
+		Outputs: g.outputs,
+		Nodes:   nodes,
+	})
+}
+
+// UnmarshalJSON fills the graph with a json content (nodes can be listed in any order)
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	type unmarshalNode struct {
+		Name   string                       `json:"name"`
+		Type   string                       `json:"type"`
+		Inputs []string                     `json:"inputs"`
+		Size   int                          `json:"size"`
+		Layers []map[string]json.RawMessage `json:"layers"`
+	}
+	type unmarshal struct {
+		Rate    float64         `json:"learning-rate"`
+		Inputs  []string        `json:"inputs"`
+		Outputs []string        `json:"outputs"`
+		Nodes   []unmarshalNode `json:"nodes"`
+	}
+	unm := unmarshal{}
+	err := json.Unmarshal(data, &unm)
+	if err != nil {
+		return err
+	}
+
+	nodes := make([]graphNode, len(unm.Nodes))
+	for i, item := range unm.Nodes {
+		layers, err := unmarshalLayers(item.Layers)
+		if err != nil {
+			return err
+		}
+		nodes[i] = graphNode{name: item.Name, kind: item.Type, inputs: item.Inputs, layers: layers, size: item.Size}
+	}
+	nodes, err = sortNodes(nodes)
+	if err != nil {
+		return err
+	}
+
+	// Rebuild the graph in topological order
+	graph := NewGraph(unm.Rate)
+	for _, node := range nodes {
+		if err := graph.addNode(node); err != nil {
+			return err
+		}
+	}
+	seen := map[string]bool{}
+	for _, input := range unm.Inputs {
+		i, ok := graph.index[input]
+		if !ok || graph.nodes[i].kind != nodeInput || seen[input] {
+			return fmt.Errorf("inputs %v do not match input nodes", unm.Inputs)
+		}
+		seen[input] = true
+		graph.inputs = append(graph.inputs, input)
+	}
+	if countKind(nodes, nodeInput) != len(graph.inputs) {
+		return fmt.Errorf("inputs %v do not match input nodes", unm.Inputs)
+	}
+	if err := graph.Output(unm.Outputs...); err != nil {
+		return err
+	}
+
+	graph.validation, graph.Stop = g.validation, g.Stop
+	*g = graph
+	return nil
+}
+
+// countKind counts the nodes of a given kind
+func countKind(nodes []graphNode, kind string) int {
+	var count int
+	for _, node := range nodes {
+		if node.kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// sortNodes orders the nodes so that each node comes after its inputs
+// (the original order is kept when possible)
+func sortNodes(nodes []graphNode) ([]graphNode, error) {
+	sorted := make([]graphNode, 0, len(nodes))
+	done := map[string]bool{}
+	for len(sorted) < len(nodes) {
+		progress := false
+		for _, node := range nodes {
+			if done[node.name] {
+				continue
+			}
+			ready := true
+			for _, input := range node.inputs {
+				ready = ready && done[input]
+			}
+			if ready {
+				sorted = append(sorted, node)
+				done[node.name] = true
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, fmt.Errorf("graph nodes contain a cycle or a missing input")
+		}
+	}
+	return sorted, nil
+}
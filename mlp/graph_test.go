@@ -0,0 +1,159 @@
+package mlp
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// graphLoss computes 0.5 * ||y - t||² on all outputs
+func graphLoss(g Graph, x, t [][]float64) float64 {
+	var loss float64
+	for k, yk := range g.Predict(x...) {
+		for j := range yk {
+			d := yk[j] - t[k][j]
+			loss += 0.5 * d * d
+		}
+	}
+	return loss
+}
+
+func TestGraph(t *testing.T) {
+	Convey("graph", t, func() {
+		// Wide (a) and deep (b) inputs, merged with a skip connection, and 2 outputs
+		g := NewGraph(0.1)
+		So(g.Input("a", 2), ShouldBeNil)
+		So(g.Input("b", 3), ShouldBeNil)
+		So(g.Dense("h1", "b", LinearBuilder{}, 2, Htan{}), ShouldBeNil)
+		So(g.Dense("h2", "h1", LinearBuilder{}, 2, Sigmoid{}), ShouldBeNil)
+		So(g.Sum("skip", "h1", "h2"), ShouldBeNil)
+		So(g.Multiply("gate", "a", "skip"), ShouldBeNil)
+		So(g.Concat("merge", "a", "gate", "h2"), ShouldBeNil)
+		So(g.Dense("y1", "merge", LinearBuilder{}, 1, Sigmoid{}), ShouldBeNil)
+		So(g.Dense("y2", "skip", LinearBuilder{}, 2, Htan{}), ShouldBeNil)
+		So(g.Output("y1", "y2"), ShouldBeNil)
+
+		So(g.Inputs(), ShouldResemble, []string{"a", "b"})
+		So(g.Outputs(), ShouldResemble, []string{"y1", "y2"})
+		size, err := g.Size("merge")
+		So(err, ShouldBeNil)
+		So(size, ShouldEqual, 6)
+
+		x := [][]float64{{0.5, -0.3}, {0.1, 0.7, -0.2}}
+		target := [][]float64{{1}, {0.2, -0.4}}
+
+		Convey("predict", func() {
+			y := g.Predict(x...)
+			So(y, ShouldHaveLength, 2)
+			So(y[0], ShouldHaveLength, 1)
+			So(y[1], ShouldHaveLength, 2)
+		})
+
+		Convey("gradients match numerical gradients", func() {
+			pass := g.forward(x)
+			yGrads := make([][]float64, 2)
+			for k, output := range g.outputs {
+				yk := pass.values[g.index[output]]
+				yGrads[k] = make([]float64, len(yk))
+				for j := range yk {
+					yGrads[k][j] = yk[j] - target[k][j]
+				}
+			}
+			g.backPropagation(pass, yGrads)
+
+			const eps = 1e-6
+			for _, layer := range g.layers() {
+				trainable, ok := layer.(Trainable)
+				if !ok {
+					continue
+				}
+				for _, param := range trainable.Parameters() {
+					for i := range param.Values {
+						v := param.Values[i]
+						param.Values[i] = v + eps
+						plus := graphLoss(g, x, target)
+						param.Values[i] = v - eps
+						minus := graphLoss(g, x, target)
+						param.Values[i] = v
+
+						numeric := (plus - minus) / (2 * eps)
+						So(math.Abs(param.Grads[i]-numeric), ShouldBeLessThan, 1e-6)
+					}
+				}
+			}
+		})
+
+		Convey("train", func() {
+			xData := [][]float64{{0.5, -0.3, 0.1, 0.7, -0.2}, {-0.5, 0.3, 0.4, -0.1, 0.6}}
+			yData := [][]float64{{1, 0.2, -0.4}, {0, -0.3, 0.5}}
+			g.Stop.OnEpoch(200)
+			g.SetValidation(xData, yData)
+			term, err := g.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+			history := term.History()
+			So(history, ShouldHaveLength, 201)
+			So(history.Last().MeanSquaredError, ShouldBeLessThan, history[0].MeanSquaredError)
+			So(history.Last().Samples, ShouldEqual, 2)
+
+			eval, err := g.Evaluate(xData, yData)
+			So(err, ShouldBeNil)
+			So(eval.MeanSquaredError, ShouldEqual, history.Last().ValidationError)
+
+			_, err = g.Train(context.Background(), [][]float64{{1, 2}}, yData[:1])
+			So(err, ShouldBeError, "input data (2) does not match nodes [a b]")
+			_, err = g.Evaluate(xData, [][]float64{{1}, {0}})
+			So(err, ShouldBeError, "output data (1) does not match nodes [y1 y2]")
+			_, err = g.Train(context.Background(), nil, nil)
+			So(err, ShouldBeError, "at least one sample expected")
+		})
+
+		Convey("json", func() {
+			data, err := json.Marshal(g)
+			So(err, ShouldBeNil)
+
+			var g2 Graph
+			So(json.Unmarshal(data, &g2), ShouldBeNil)
+			So(g2, ShouldResemble, g)
+			So(g2.Predict(x...), ShouldResemble, g.Predict(x...))
+
+			// Nodes can be listed in any order
+			var raw map[string]any
+			So(json.Unmarshal(data, &raw), ShouldBeNil)
+			nodes := raw["nodes"].([]any)
+			for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+				nodes[i], nodes[j] = nodes[j], nodes[i]
+			}
+			data, err = json.Marshal(raw)
+			So(err, ShouldBeNil)
+			var g3 Graph
+			So(json.Unmarshal(data, &g3), ShouldBeNil)
+			So(g3.Predict(x...), ShouldResemble, g.Predict(x...))
+
+			// Cycles
+			err = json.Unmarshal([]byte(`{"inputs":["a"],"outputs":["c"],"nodes":[
+				{"name":"a","type":"input","size":1},
+				{"name":"b","type":"sum","inputs":["a","c"]},
+				{"name":"c","type":"sum","inputs":["a","b"]}]}`), &g3)
+			So(err, ShouldBeError, "graph nodes contain a cycle or a missing input")
+			err = json.Unmarshal([]byte(`{"inputs":["b"],"outputs":["a"],"nodes":[
+				{"name":"a","type":"input","size":1}]}`), &g3)
+			So(err, ShouldBeError, "inputs [b] do not match input nodes")
+		})
+
+		Convey("errors", func() {
+			So(g.Input("a", 1), ShouldBeError, `node "a" already exists`)
+			So(g.Input("c", 0), ShouldBeError, `node "c": positive size expected`)
+			So(g.Input("", 1), ShouldBeError, "node name expected")
+			So(g.Sum("c", "a", "b"), ShouldBeError, `node "c": input "b" (3) does not match input "a" (2)`)
+			So(g.Multiply("c", "a"), ShouldBeError, `node "c": at least two inputs expected`)
+			So(g.Concat("c", "a", "z"), ShouldBeError, `node "c": node "z" not found`)
+			So(g.Sequence("c", "a", NewLinear(3, 1)), ShouldBeError, `node "c": linear layer inputs (3) do not match 2 values`)
+			So(g.Sequence("c", "a"), ShouldBeError, `node "c": at least one layer expected`)
+			So(g.Output("z"), ShouldBeError, `node "z" not found`)
+			So(g.Output("y1"), ShouldBeError, `node "y1" is already an output`)
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package mlp
+
+import (
+	"fmt"
+	"math"
+)
+
+// Initializer draws the initial weights of a layer
+type Initializer interface {
+	Weight(in, out int) float64
+	String() string
+}
+
+// Normal draws weights from the standard normal distribution
+type Normal struct{}
+
+// Weight normal = N(0, 1)
+func (n Normal) Weight(in, out int) float64 {
+	return normRandom(1, 0)
+}
+
+// String converts to constants
+func (n Normal) String() string {
+	return "normal"
+}
+
+// Xavier (or Glorot) initialization, suitable for sigmoid and htan activators
+type Xavier struct{}
+
+// Weight xavier = N(0, 2/(in+out))
+func (x Xavier) Weight(in, out int) float64 {
+	return normRandom(math.Sqrt(2/float64(in+out)), 0)
+}
+
+// String converts to constants
+func (x Xavier) String() string {
+	return "xavier"
+}
+
+// He initialization, suitable for relu activators
+type He struct{}
+
+// Weight he = N(0, 2/in)
+func (h He) Weight(in, out int) float64 {
+	return normRandom(math.Sqrt(2/float64(in)), 0)
+}
+
+// String converts to constants
+func (h He) String() string {
+	return "he"
+}
+
+// ParseInitializer converts a constant to an initializer
+func ParseInitializer(name string) (Initializer, error) {
+	switch name {
+	case "normal":
+		return Normal{}, nil
+	case "xavier":
+		return Xavier{}, nil
+	case "he":
+		return He{}, nil
+	default:
+		return nil, fmt.Errorf("unknown initializer %q", name)
+	}
+}
@@ -0,0 +1,167 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Prediction of a class with its score
+type Prediction struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+func (p Prediction) String() string {
+	return fmt.Sprintf("%s: %.2f", p.Label, p.Score)
+}
+
+// LabelEncoder converts class labels to one-hot outputs and outputs to labels
+type LabelEncoder struct {
+	classes []string       // Label of each output
+	index   map[string]int // Output of each label
+}
+
+// NewLabelEncoder builds an encoder, the ith class being the ith output
+func NewLabelEncoder(classes ...string) (*LabelEncoder, error) {
+	index := make(map[string]int, len(classes))
+	for i, class := range classes {
+		if _, ok := index[class]; ok {
+			return nil, fmt.Errorf("duplicated class %q", class)
+		}
+		index[class] = i
+	}
+	return &LabelEncoder{
+		classes: classes,
+		index:   index,
+	}, nil
+}
+
+// FitLabels builds an encoder using the sorted distinct labels
+func FitLabels(labels []string) *LabelEncoder {
+	seen := map[string]bool{}
+	var classes []string
+	for _, label := range labels {
+		if !seen[label] {
+			seen[label] = true
+			classes = append(classes, label)
+		}
+	}
+	sort.Strings(classes)
+	enc, _ := NewLabelEncoder(classes...) // no duplicate
+	return enc
+}
+
+// FitIntLabels builds an encoder using the sorted distinct int labels
+func FitIntLabels(labels []int) *LabelEncoder {
+	seen := map[int]bool{}
+	var values []int
+	for _, label := range labels {
+		if !seen[label] {
+			seen[label] = true
+			values = append(values, label)
+		}
+	}
+	sort.Ints(values)
+	classes := make([]string, len(values))
+	for i, value := range values {
+		classes[i] = strconv.Itoa(value)
+	}
+	enc, _ := NewLabelEncoder(classes...) // no duplicate
+	return enc
+}
+
+// Classes returns the label of each output
+func (enc *LabelEncoder) Classes() []string {
+	return append([]string(nil), enc.classes...)
+}
+
+// Encode converts labels to a one-hot (or multi-hot if several labels) output
+func (enc *LabelEncoder) Encode(labels ...string) ([]float64, error) {
+	output := newVector(len(enc.classes)).zeros()
+	for _, label := range labels {
+		i, ok := enc.index[label]
+		if !ok {
+			return nil, fmt.Errorf("unknown label %q", label)
+		}
+		output[i] = 1
+	}
+	return output, nil
+}
+
+// EncodeAll converts a list of labels to one-hot outputs
+func (enc *LabelEncoder) EncodeAll(labels []string) ([][]float64, error) {
+	outputs := make([][]float64, len(labels))
+	for i, label := range labels {
+		output, err := enc.Encode(label)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = output
+	}
+	return outputs, nil
+}
+
+// Decode returns the k best classes of an output, highest score first
+func (enc *LabelEncoder) Decode(output []float64, k int) ([]Prediction, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("number of classes (%d) shall not be negative", k)
+	}
+	predictions, err := enc.predictions(output)
+	if err != nil {
+		return nil, err
+	}
+	if k < len(predictions) {
+		predictions = predictions[:k]
+	}
+	return predictions, nil
+}
+
+// DecodeMulti returns all classes of an output scoring at least the threshold, highest score first
+func (enc *LabelEncoder) DecodeMulti(output []float64, threshold float64) ([]Prediction, error) {
+	predictions, err := enc.predictions(output)
+	if err != nil {
+		return nil, err
+	}
+	n := sort.Search(len(predictions), func(i int) bool {
+		return predictions[i].Score < threshold
+	})
+	return predictions[:n], nil
+}
+
+// predictions sorts the classes by score
+func (enc *LabelEncoder) predictions(output []float64) ([]Prediction, error) {
+	if len(output) != len(enc.classes) {
+		return nil, fmt.Errorf("output (%d) does not match classes (%d)", len(output), len(enc.classes))
+	}
+	predictions := make([]Prediction, len(output))
+	for i, score := range output {
+		predictions[i] = Prediction{
+			Label: enc.classes[i],
+			Score: score,
+		}
+	}
+	sort.SliceStable(predictions, func(i, j int) bool {
+		return predictions[i].Score > predictions[j].Score
+	})
+	return predictions, nil
+}
+
+func (enc *LabelEncoder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(enc.classes)
+}
+
+func (enc *LabelEncoder) UnmarshalJSON(data []byte) error {
+	var classes []string
+	err := json.Unmarshal(data, &classes)
+	if err != nil {
+		return err
+	}
+	loaded, err := NewLabelEncoder(classes...)
+	if err != nil {
+		return err
+	}
+	*enc = *loaded
+	return nil
+}
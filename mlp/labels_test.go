@@ -0,0 +1,94 @@
+package mlp
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLabelEncoder(t *testing.T) {
+	Convey("label encoder", t, func() {
+		Convey("fit", func() {
+			enc := FitLabels([]string{"dog", "cat", "bird", "cat"})
+			So(enc.Classes(), ShouldResemble, []string{"bird", "cat", "dog"})
+
+			enc = FitIntLabels([]int{10, 2, 2, 1})
+			So(enc.Classes(), ShouldResemble, []string{"1", "2", "10"})
+
+			_, err := NewLabelEncoder("a", "b", "a")
+			So(err, ShouldBeError, `duplicated class "a"`)
+		})
+
+		Convey("encode", func() {
+			enc, err := NewLabelEncoder("cat", "dog", "bird")
+			So(err, ShouldBeNil)
+
+			output, err := enc.Encode("dog")
+			So(err, ShouldBeNil)
+			So(output, ShouldResemble, []float64{0, 1, 0})
+
+			output, err = enc.Encode("cat", "bird")
+			So(err, ShouldBeNil)
+			So(output, ShouldResemble, []float64{1, 0, 1})
+
+			outputs, err := enc.EncodeAll([]string{"bird", "cat"})
+			So(err, ShouldBeNil)
+			So(outputs, ShouldResemble, [][]float64{{0, 0, 1}, {1, 0, 0}})
+
+			_, err = enc.Encode("fish")
+			So(err, ShouldBeError, `unknown label "fish"`)
+		})
+
+		Convey("decode", func() {
+			enc, err := NewLabelEncoder("cat", "dog", "bird")
+			So(err, ShouldBeNil)
+
+			predictions, err := enc.Decode([]float64{0.93, 0.02, 0.4}, 2)
+			So(err, ShouldBeNil)
+			So(predictions, ShouldResemble, []Prediction{{"cat", 0.93}, {"bird", 0.4}})
+			So(predictions[0].String(), ShouldEqual, "cat: 0.93")
+
+			predictions, err = enc.Decode([]float64{0.93, 0.02, 0.4}, 5)
+			So(err, ShouldBeNil)
+			So(predictions, ShouldHaveLength, 3)
+
+			predictions, err = enc.DecodeMulti([]float64{0.6, 0.2, 0.7}, 0.5)
+			So(err, ShouldBeNil)
+			So(predictions, ShouldResemble, []Prediction{{"bird", 0.7}, {"cat", 0.6}})
+
+			_, err = enc.Decode([]float64{1}, 1)
+			So(err, ShouldBeError, "output (1) does not match classes (3)")
+			_, err = enc.Decode([]float64{0.93, 0.02, 0.4}, -1)
+			So(err, ShouldBeError, "number of classes (-1) shall not be negative")
+		})
+
+		Convey("stored with the network", func() {
+			rand.Seed(42)
+			net1 := NewNetwork(0.3, 2)
+			net1.AddLayer(LinearBuilder{}, 3, Sigmoid{})
+
+			_, err := net1.Classify([]float64{0, 1}, 1)
+			So(err, ShouldBeError, "labels shall be set")
+
+			enc, err := NewLabelEncoder("cat", "dog", "bird")
+			So(err, ShouldBeNil)
+			net1.SetLabels(enc)
+
+			js, err := net1.MarshalJSON()
+			So(err, ShouldBeNil)
+			So(string(js), ShouldContainSubstring, `"labels":["cat","dog","bird"]`)
+			net2 := Network{}
+			So(net2.UnmarshalJSON(js), ShouldBeNil)
+			So(net2.Labels(), ShouldResemble, enc)
+
+			predictions, err := net2.Classify([]float64{0, 1}, 1)
+			So(err, ShouldBeNil)
+			So(predictions, ShouldHaveLength, 1)
+			all, err := net2.ClassifyMulti([]float64{0, 1}, 0)
+			So(err, ShouldBeNil)
+			So(all, ShouldHaveLength, 3)
+			So(all[0], ShouldResemble, predictions[0])
+		})
+	})
+}
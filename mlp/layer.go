@@ -13,6 +13,44 @@ type Layer interface {
 	Type() string
 }
 
+// Parameter is a list of trainable values of a layer with their accumulated gradients
+// (slices share the memory of the layer)
+type Parameter struct {
+	Name   string
+	Values []float64
+	Grads  []float64
+}
+
+// Trainable is implemented by layers having parameters
+type Trainable interface {
+	Parameters() []Parameter
+}
+
+// sparseTrainable is implemented by trainable layers computing the gradient norm without listing all their parameters
+type sparseTrainable interface {
+	gradientNorm2() float64 // Squared euclidean norm of the accumulated gradients
+}
+
+// gradientNorm2 computes the squared euclidean norm of the accumulated gradients of a layer
+func gradientNorm2(trainable Trainable) float64 {
+	if sparse, ok := trainable.(sparseTrainable); ok {
+		return sparse.gradientNorm2()
+	}
+	var sum float64
+	for _, param := range trainable.Parameters() {
+		for _, grad := range param.Grads {
+			sum += grad * grad
+		}
+	}
+	return sum
+}
+
+// Regularization adds a penalty on the weights: l1 * |w| + l2 * w² / 2
+type Regularization struct {
+	L1 float64 `json:"l1,omitempty"`
+	L2 float64 `json:"l2,omitempty"`
+}
+
 // Linear layer applies a linear transformation
 // y = x.w + b
 type Linear struct {
@@ -21,16 +59,53 @@ type Linear struct {
 
 	weights     matrix // d = in x out
 	weightsGrad matrix // d = in x out
+
+	initializer    string         // Name of the initializer (empty if default)
+	regularization Regularization // Weights penalty
 }
 
 // NewLinear allocates the linear layer
 func NewLinear(in, out int) Linear {
+	return newLinear(in, out, nil, Regularization{})
+}
+
+// NewLinearWeights builds a linear layer from a copy of its weights (inputs x outputs) and biases
+func NewLinearWeights(weights [][]float64, biases []float64) (Linear, error) {
+	if len(weights) == 0 || len(biases) == 0 {
+		return Linear{}, fmt.Errorf("cannot load 0 lentgh matrix")
+	}
+	ln := Linear{
+		weights:     newMatrix(len(weights), len(biases)),
+		weightsGrad: newMatrix(len(weights), len(biases)).zeros(),
+		biaises:     append(newVector(0), biases...),
+		biaisesGrad: newVector(len(biases)).zeros(),
+	}
+	for i, row := range weights {
+		if len(row) != len(biases) {
+			return Linear{}, fmt.Errorf("weights row %d (%d) does not match biases (%d)", i, len(row), len(biases))
+		}
+		copy(ln.weights[i], row)
+	}
+	return ln, nil
+}
+
+// newLinear allocates the linear layer using an initializer (default if nil)
+func newLinear(in, out int, init Initializer, reg Regularization) Linear {
+	var name string
+	if init == nil {
+		init = Normal{}
+	} else {
+		name = init.String()
+	}
+
 	weights := newMatrix(in, out)
 	return Linear{
-		weights:     weights.iter(func(i, j int) { weights[i][j] = normRandom(1, 0) }),
-		weightsGrad: newMatrix(in, out).zeros(),
-		biaises:     newVector(out).zeros(),
-		biaisesGrad: newVector(out).zeros(),
+		weights:        weights.iter(func(i, j int) { weights[i][j] = init.Weight(in, out) }),
+		weightsGrad:    newMatrix(in, out).zeros(),
+		biaises:        newVector(out).zeros(),
+		biaisesGrad:    newVector(out).zeros(),
+		initializer:    name,
+		regularization: reg,
 	}
 }
 
@@ -73,9 +148,15 @@ func (ln Linear) Update(learningRate float64) {
 		ln.biaises[j] -= learningRate * ln.biaisesGrad[j]
 	})
 
-	// weights -= rate * gradient weights
+	// weights -= rate * (gradient weights + penalty gradient)
+	l1, l2 := ln.regularization.L1, ln.regularization.L2
 	ln.weights.iter(func(i, j int) {
-		ln.weights[i][j] -= learningRate * ln.weightsGrad[i][j]
+		w := ln.weights[i][j]
+		grad := ln.weightsGrad[i][j]
+		if l1 != 0 || l2 != 0 {
+			grad += l1*sign(w) + l2*w
+		}
+		ln.weights[i][j] -= learningRate * grad
 	})
 
 	// Clear gradients
@@ -83,20 +164,71 @@ func (ln Linear) Update(learningRate float64) {
 	ln.weightsGrad.zeros()
 }
 
+// Parameters returns the biases and each row of weights
+func (ln Linear) Parameters() []Parameter {
+	params := make([]Parameter, 0, 1+len(ln.weights))
+	params = append(params, Parameter{Name: "biases", Values: ln.biaises, Grads: ln.biaisesGrad})
+	for i := range ln.weights {
+		params = append(params, Parameter{
+			Name:   fmt.Sprintf("weights[%d]", i),
+			Values: ln.weights[i],
+			Grads:  ln.weightsGrad[i],
+		})
+	}
+	return params
+}
+
 func (ln Linear) Type() string {
 	return "linear"
 }
 
+// Shape returns the number of inputs and outputs
+func (ln Linear) Shape() (int, int) {
+	return len(ln.weights), len(ln.biaises)
+}
+
+// Weights returns a copy of the weights (inputs x outputs)
+func (ln Linear) Weights() [][]float64 {
+	weights := make([][]float64, len(ln.weights))
+	for i, row := range ln.weights {
+		weights[i] = append([]float64(nil), row...)
+	}
+	return weights
+}
+
+// Biases returns a copy of the biases
+func (ln Linear) Biases() []float64 {
+	return append([]float64(nil), ln.biaises...)
+}
+
+// summary describes the layer
+func (ln Linear) summary(in int) LayerSummary {
+	_, out := ln.Shape()
+	return LayerSummary{
+		Type:       ln.Type(),
+		Inputs:     in,
+		Outputs:    out,
+		Parameters: len(ln.weights)*out + out,
+	}
+}
+
 type exportLayer struct {
-	Weights matrix `json:"weights"`
-	Biaises vector `json:"biaises"`
+	Weights        matrix          `json:"weights"`
+	Biaises        vector          `json:"biaises"`
+	Initializer    string          `json:"init,omitempty"`
+	Regularization *Regularization `json:"regularization,omitempty"`
 }
 
 func (ln Linear) MarshalJSON() ([]byte, error) {
-	return json.Marshal(exportLayer{
-		Weights: ln.weights,
-		Biaises: ln.biaises,
-	})
+	exp := exportLayer{
+		Weights:     ln.weights,
+		Biaises:     ln.biaises,
+		Initializer: ln.initializer,
+	}
+	if ln.regularization != (Regularization{}) {
+		exp.Regularization = &ln.regularization
+	}
+	return json.Marshal(exp)
 }
 
 func (ln *Linear) UnmarshalJSON(data []byte) error {
@@ -107,15 +239,15 @@ func (ln *Linear) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	// Check dimensions
-	if len(exp.Biaises) == 0 || len(exp.Weights) == 0 || len(exp.Weights[0]) == 0 {
-		return fmt.Errorf("cannot load 0 lentgh matrix")
+	// Check dimensions and copy
+	loaded, err := NewLinearWeights(exp.Weights, exp.Biaises)
+	if err != nil {
+		return err
 	}
-
-	// Copy or init
-	ln.biaises = exp.Biaises
-	ln.weights = exp.Weights
-	ln.biaisesGrad = newVector(len(exp.Biaises)).zeros()
-	ln.weightsGrad = newMatrix(len(exp.Weights), len(exp.Weights[0])).zeros()
+	loaded.initializer = exp.Initializer
+	if exp.Regularization != nil {
+		loaded.regularization = *exp.Regularization
+	}
+	*ln = loaded
 	return nil
 }
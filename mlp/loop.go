@@ -0,0 +1,164 @@
+package mlp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// model is trained and evaluated sample by sample by the shared loops (Network and Graph)
+type model interface {
+	step(x, y []float64) ([]float64, error)         // Computes the outputs of a training sample and accumulates the gradients
+	gradientNorm() float64                          // Euclidean norm of the accumulated gradients
+	update()                                        // Updates the weights and clears the gradients
+	score(x, y []float64) (float64, float64, error) // Squared error and ratio of well classified outputs of a sample
+}
+
+// trainLoop trains a model epoch by epoch until one of the stop criteria is reached
+type trainLoop struct {
+	model        model
+	learningRate float64
+	validation   Dataset                     // Evaluated at the end of each epoch (optional)
+	stop         Termination                 // Ending conditions
+	history      History                     // Epochs already done (resumed training)
+	elapsed      time.Duration               // Duration of the epochs already done
+	onEpoch      func(history History) error // Called at the end of each epoch (optional)
+}
+
+// run trains the model from the last epoch of the history
+func (tl trainLoop) run(ctx context.Context, ds Dataset) (Termination, error) {
+	start := time.Now()
+	history := tl.history
+	var nonFinite int
+	if len(history) > 0 {
+		nonFinite = history.Last().NonFinite
+	}
+	for epoch := len(history); ; epoch++ { // epoch, no ending condition
+		epochStart := time.Now()
+		metrics, err := tl.epoch(ctx, ds)
+		if err != nil {
+			return Termination{history: history}, err
+		}
+		nonFinite += metrics.NonFinite
+
+		metrics.Epoch = epoch
+		metrics.NonFinite = nonFinite
+		metrics.ValidationError = math.NaN()
+		metrics.ValidationAccuracy = math.NaN()
+		if tl.validation != nil {
+			eval, err := evaluate(tl.model, tl.validation)
+			if err != nil {
+				return Termination{history: history}, err
+			}
+			metrics.ValidationError = eval.MeanSquaredError
+			metrics.ValidationAccuracy = eval.Accuracy
+		}
+		metrics.Duration = tl.elapsed + time.Since(start)
+		metrics.EpochDuration = time.Since(epochStart)
+		history = append(history, metrics)
+
+		if tl.onEpoch != nil {
+			if err := tl.onEpoch(history); err != nil {
+				return Termination{history: history}, err
+			}
+		}
+		if reached := tl.stop.hasReached(history); reached != nil {
+			return Termination{reached: reached, history: history}, nil
+		}
+	}
+}
+
+// epoch trains the model on one pass over the dataset
+// return the metrics computed on the epoch
+func (tl trainLoop) epoch(ctx context.Context, ds Dataset) (Metrics, error) {
+	it, err := ds.Open()
+	if err != nil {
+		return Metrics{}, err
+	}
+	defer it.Close()
+
+	var metrics Metrics
+	var samples, finite int
+	for {
+		// Listen to context
+		select {
+		case <-ctx.Done():
+			return Metrics{}, ctx.Err()
+		default:
+		}
+
+		xi, yi, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Metrics{}, err
+		}
+		y, err := tl.model.step(xi, yi) // Compute y and gradients
+		if err != nil {
+			return Metrics{}, err
+		}
+
+		// Sum errors and gradient norms
+		mse := meanSquaredError(y, yi)
+		if math.IsNaN(mse) || math.IsInf(mse, 0) {
+			metrics.NonFinite++
+		} else {
+			metrics.MeanSquaredError += mse
+			finite++
+		}
+		metrics.GradientNorm += tl.model.gradientNorm()
+		samples++
+
+		tl.model.update() // Udpate weights
+	}
+	if samples == 0 {
+		return Metrics{}, fmt.Errorf("at least one sample expected")
+	}
+
+	metrics.MeanSquaredError /= float64(finite)
+	if finite == 0 {
+		metrics.MeanSquaredError = math.Inf(1) // no finite error
+	}
+	metrics.GradientNorm /= float64(samples)
+	metrics.Samples = samples
+	metrics.LearningRate = tl.learningRate
+	return metrics, nil
+}
+
+// evaluate computes the error and the accuracy of the model on lazily loaded samples
+func evaluate(m model, ds Dataset) (Evaluation, error) {
+	it, err := ds.Open()
+	if err != nil {
+		return Evaluation{}, err
+	}
+	defer it.Close()
+
+	var eval Evaluation
+	var samples int
+	for {
+		xi, yi, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Evaluation{}, err
+		}
+		mse, accuracy, err := m.score(xi, yi)
+		if err != nil {
+			return Evaluation{}, err
+		}
+		eval.MeanSquaredError += mse
+		eval.Accuracy += accuracy
+		samples++
+	}
+	if samples == 0 {
+		return Evaluation{}, fmt.Errorf("at least one sample expected")
+	}
+
+	eval.MeanSquaredError /= float64(samples)
+	eval.Accuracy /= float64(samples)
+	return eval, nil
+}
@@ -13,7 +13,38 @@ func meanSquaredError(v1, v2 vector) float64 {
 	return sum / float64(len(v1))
 }
 
+// sign returns -1, 0 or 1
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
 // random float using standard deviation and mean
 func normRandom(stdDev, mean float64) float64 {
 	return rand.NormFloat64()*stdDev + mean
 }
+
+// classify returns the class of an output:
+// the index of the highest value, or 0/1 for a single value
+func classify(v vector) int {
+	if len(v) == 1 {
+		if v[0] >= 0.5 {
+			return 1
+		}
+		return 0
+	}
+
+	result := 0
+	v.iter(func(i int) {
+		if v[i] > v[result] {
+			result = i
+		}
+	})
+	return result
+}
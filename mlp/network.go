@@ -6,16 +6,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
 )
 
 type Network struct {
-	layers       []Layer     // List of layers
-	inputs       [][]float64 // Memo input
-	learningRate float64     // Learning rate
-	neurons      []int       // Number of neurons at each layer
+	layers       []Layer       // List of layers
+	inputs       [][]float64   // Memo input
+	learningRate float64       // Learning rate
+	neurons      []int         // Number of neurons at each layer
+	frozen       map[int]bool  // Non trainable layers
+	validation   Dataset       // Validation data
+	random       *Source       // Random source used during the training
+	scaler       Scaler        // Optional inputs preprocessing
+	labels       *LabelEncoder // Optional class of each output
+	observers    []Observer    // Notified at the end of each epoch
+	logger       *slog.Logger  // Optional training events logger
 
-	Stop Termination // Ending conditions
+	Stop       Termination // Ending conditions
+	Checkpoint Checkpoint  // Training state backups
 }
 
 // NewNetwork builds an empty network with a given number of inputs
@@ -23,9 +34,67 @@ func NewNetwork(learningRate float64, in int) Network {
 	return Network{
 		learningRate: learningRate,
 		neurons:      []int{in},
+		random:       NewSource(1),
 	}
 }
 
+// Seed initializes the random source used during the training
+func (net *Network) Seed(seed int64) {
+	net.random = NewSource(seed)
+}
+
+// Rand returns a generator using the random source of the network.
+// Any randomness required during the training shall use it,
+// so that its state is saved in checkpoints and resumed runs are reproduced.
+func (net *Network) Rand() *rand.Rand {
+	if net.random == nil {
+		net.random = NewSource(1)
+	}
+	return rand.New(net.random)
+}
+
+// SetScaler attaches a preprocessing stage to the inputs:
+// Train fits it on the training data if not fitted yet, then Train, Evaluate and Predict take raw inputs.
+// A fitted scaler shall have one feature per input neuron.
+func (net *Network) SetScaler(scaler Scaler) error {
+	attached := *net
+	attached.scaler = scaler
+	if err := attached.checkScaler(); err != nil {
+		return err
+	}
+	net.scaler = scaler
+	return nil
+}
+
+// Scaler returns the preprocessing stage of the inputs, if set
+func (net Network) Scaler() Scaler {
+	return net.scaler
+}
+
+// scale applies the preprocessing stage to raw inputs, if any
+func (net Network) scale(x []float64) []float64 {
+	if net.scaler == nil {
+		return x
+	}
+	return net.scaler.Transform(x)
+}
+
+// checkScaler checks that the fitted scaler, if any, has one feature per input neuron
+func (net Network) checkScaler() error {
+	fs, ok := net.scaler.(featureScaler)
+	if !ok || !net.scaler.Fitted() || len(net.neurons) == 0 {
+		return nil
+	}
+	n, err := fs.features()
+	if err != nil {
+		return err
+	}
+	if n != net.in() {
+		return fmt.Errorf("scaler %q features (%d) do not match input neurons (%d)", net.scaler.Type(), n, net.in())
+	}
+	return nil
+}
+
 // in computes the number of inputs
 func (net Network) in() int {
 	return net.neurons[0]
@@ -36,6 +105,21 @@ func (net Network) out() int {
 	return net.neurons[len(net.neurons)-1]
 }
 
+// Layers returns the layers of the network (linear layers followed by their activator layer)
+func (net Network) Layers() []Layer {
+	return append([]Layer(nil), net.layers...)
+}
+
+// Neurons returns the number of inputs followed by the number of neurons of each layer
+func (net Network) Neurons() []int {
+	return append([]int(nil), net.neurons...)
+}
+
+// LearningRate returns the learning rate of the network
+func (net Network) LearningRate() float64 {
+	return net.learningRate
+}
+
 // AddLayer pushes a new layer to the network
 func (net *Network) AddLayer(bld LayerBuilder, neurons int, act Activator) {
 	// Save neurons nb
@@ -46,10 +130,39 @@ func (net *Network) AddLayer(bld LayerBuilder, neurons int, act Activator) {
 	net.layers = append(
 		net.layers,
 		bld.New(lastOut, neurons),
-		newActivatorLayer(act),
+		NewActivatorLayer(act),
 	)
 }
 
+// Add appends a layer to the network, for instance an embedding layer before linear layers
+func (net *Network) Add(layer Layer) error {
+	out, err := outputSize(net.out(), []Layer{layer})
+	if err != nil {
+		return err
+	}
+	net.layers = append(net.layers, layer)
+	if _, ok := layer.(summarizer); ok {
+		net.neurons = append(net.neurons, out)
+	}
+	return nil
+}
+
+// OutputShape returns the shape of the image (or sequence) produced by the last image layers (convolution, pooling),
+// false if the network does not end with image layers
+func (net Network) OutputShape() (Shape, bool) {
+	var shape Shape
+	var ok bool
+	for _, layer := range net.layers {
+		switch l := layer.(type) {
+		case interface{ OutputShape() Shape }:
+			shape, ok = l.OutputShape(), true
+		case summarizer:
+			ok = false
+		}
+	}
+	return shape, ok
+}
+
 // feedForward the input into the whole network
 func (net *Network) feedForward(x []float64) []float64 {
 	// Clear list of inputs
@@ -76,7 +189,11 @@ func (net Network) backPropagation(yGrad []float64) {
 
 // update all layers
 func (net Network) update() {
-	for _, layer := range net.layers {
+	for i, layer := range net.layers {
+		if net.frozen[i] {
+			clearGrads([]Layer{layer})
+			continue
+		}
 		layer.Update(net.learningRate)
 	}
 }
@@ -95,93 +212,241 @@ func (net Network) check(in, out []float64) error {
 	}
 }
 
-// train the network on one epoch
-// return the last computed output and the last expected output
-func (net Network) trainOneEpoch(
-	ctx context.Context, xData, yData [][]float64,
-) ([]float64, []float64, error) {
-	if len(xData) != len(yData) {
-		return nil, nil, fmt.Errorf("input / output should have the same length")
+// step computes the outputs of a training sample and accumulates the gradients
+func (net Network) step(x, y []float64) ([]float64, error) {
+	if err := net.check(x, y); err != nil {
+		return nil, err
 	}
 
-	var y []float64 // last y
-	for i, xi := range xData {
-		// Listen to context
-		// select {
-		// case <-ctx.Done():
-		// 	return nil, nil, ctx.Err()
-		// }
+	out := net.feedForward(net.scale(x)) // Compute y
+	yGrad := newVector(len(y))           // Gradient = y - target
+	yGrad.iter(func(j int) {
+		yGrad[j] = out[j] - y[j]
+	})
+	net.backPropagation(yGrad) // Compute gradients
+	return out, nil
+}
 
-		yi := yData[i]
-		if err := net.check(xi, yi); err != nil {
-			return nil, nil, err
+// gradientNorm computes the euclidean norm of all accumulated gradients
+func (net Network) gradientNorm() float64 {
+	var sum float64
+	for i, layer := range net.layers {
+		trainable, ok := layer.(Trainable)
+		if !ok || net.frozen[i] {
+			continue
 		}
+		sum += gradientNorm2(trainable)
+	}
+	return math.Sqrt(sum)
+}
 
-		y = net.feedForward(xi)     // Compute y
-		yGrad := newVector(len(yi)) // Gradient = y - target
-		yGrad.iter(func(j int) {
-			yGrad[j] = y[j] - yi[j]
-		})
+// SetValidation sets the data evaluated at the end of each epoch
+func (net *Network) SetValidation(xData, yData [][]float64) {
+	net.SetValidationDataset(Slices(xData, yData))
+}
 
-		net.backPropagation(yGrad) // Compute gradients
-		net.update()               // Udpate weights
-	}
+// SetValidationDataset sets the dataset evaluated at the end of each epoch
+func (net *Network) SetValidationDataset(ds Dataset) {
+	net.validation = ds
+}
 
-	return y, yData[len(yData)-1], nil
+// Observe adds observers notified with the metrics at the end of each epoch
+func (net *Network) Observe(observers ...Observer) {
+	net.observers = append(net.observers, observers...)
 }
 
+// Train the network until one of the stop criteria is reached
 func (net Network) Train(ctx context.Context, xData, yData [][]float64) (Termination, error) {
-	start := time.Now()
-	for epoch := 0; ; epoch++ { // epoch, no ending condition
-		yLast, yDataLast, err := net.trainOneEpoch(ctx, xData, yData)
-		if err != nil {
-			return Termination{}, err
-		}
+	if err := net.fitScaler(xData); err != nil {
+		return Termination{}, err
+	}
+	return net.train(ctx, Slices(xData, yData), trainingState{})
+}
 
-		// Only compute what is checked
-		current := Termination{}
-		if net.Stop.epoch != nil {
-			current.epoch = &epoch
-		}
-		if net.Stop.duration != nil {
-			duration := time.Since(start)
-			current.duration = &duration
-		}
-		if net.Stop.meanSquaredError != nil {
-			mse := meanSquaredError(yLast, yDataLast)
-			current.meanSquaredError = &mse
-		}
-		if net.Stop.hasReached(current) {
-			mse := meanSquaredError(yLast, yDataLast)
-			current.meanSquaredError = &mse
-			return current, nil
-		}
+// TrainDataset trains the network on lazily loaded samples until one of the stop criteria is reached.
+// The scaler, if any, shall be fitted.
+func (net Network) TrainDataset(ctx context.Context, ds Dataset) (Termination, error) {
+	return net.train(ctx, ds, trainingState{})
+}
+
+// Resume loads a checkpoint into the network and continues the training.
+// Stop criteria, checkpoints and validation data are not saved and shall be set again.
+func (net *Network) Resume(ctx context.Context, checkpoint io.Reader, xData, yData [][]float64) (Termination, error) {
+	return net.ResumeDataset(ctx, checkpoint, Slices(xData, yData))
+}
+
+// ResumeDataset loads a checkpoint into the network and continues the training on lazily loaded samples.
+func (net *Network) ResumeDataset(ctx context.Context, checkpoint io.Reader, ds Dataset) (Termination, error) {
+	var state trainingState
+	err := json.NewDecoder(checkpoint).Decode(&state)
+	if err != nil {
+		return Termination{}, err
 	}
+
+	// Keep the training configuration, load the model
+	model := state.network
+	model.validation = net.validation
+	model.observers, model.logger = net.observers, net.logger
+	model.Stop, model.Checkpoint = net.Stop, net.Checkpoint
+	model.random = &Source{state: state.random}
+	*net = *model
+	return net.train(ctx, ds, state)
 }
 
-// Predict takes a vector of inputs and computes a vector of outputs
+// fitScaler fits the scaler on the training data, if not fitted yet
+func (net Network) fitScaler(xData [][]float64) error {
+	if net.scaler == nil || net.scaler.Fitted() {
+		return nil
+	}
+	if err := net.scaler.Fit(xData); err != nil {
+		return err
+	}
+	return net.checkScaler()
+}
+
+// train the network from a given state
+func (net Network) train(ctx context.Context, ds Dataset, state trainingState) (Termination, error) {
+	if len(net.layers) == 0 {
+		return Termination{}, fmt.Errorf("at least one layer expected")
+	}
+	if net.random == nil {
+		net.random = NewSource(1)
+	}
+	if net.scaler != nil && !net.scaler.Fitted() {
+		return Termination{}, fmt.Errorf("scaler %q shall be fitted", net.scaler.Type())
+	}
+	if err := net.checkScaler(); err != nil {
+		return Termination{}, err
+	}
+
+	net.logStart(len(state.history))
+	term, err := net.epochs(ctx, ds, state)
+	net.logStop(term, err)
+	return term, err
+}
+
+// epochs trains the network until one of the stop criteria is reached
+func (net Network) epochs(ctx context.Context, ds Dataset, state trainingState) (Termination, error) {
+	return trainLoop{
+		model:        net,
+		learningRate: net.learningRate,
+		validation:   net.validation,
+		stop:         net.Stop,
+		history:      state.history,
+		elapsed:      state.elapsed,
+		onEpoch: func(history History) error {
+			metrics := history.Last()
+			net.logEpoch(metrics)
+			for _, obs := range net.observers {
+				obs.Observe(metrics)
+			}
+
+			// Save state
+			if !net.Checkpoint.shallSave(history) {
+				return nil
+			}
+			err := net.Checkpoint.save(trainingState{
+				network: &net,
+				elapsed: metrics.Duration,
+				random:  net.random.state,
+				history: history,
+			})
+			if err != nil {
+				return err
+			}
+			net.logCheckpoint(metrics.Epoch)
+			return nil
+		},
+	}.run(ctx, ds)
+}
+
+// Evaluation of the network on a data set
+type Evaluation struct {
+	MeanSquaredError float64 // Mean of the sample errors
+	Accuracy         float64 // Ratio of well classified samples
+}
+
+// Evaluate computes the error and the accuracy of the network on the given data.
+// A sample is well classified if the highest output matches the highest expected output
+// (or if both are on the same side of 0.5 for a single output)
+func (net Network) Evaluate(xData, yData [][]float64) (Evaluation, error) {
+	return net.EvaluateDataset(Slices(xData, yData))
+}
+
+// EvaluateDataset computes the error and the accuracy of the network on lazily loaded samples.
+func (net Network) EvaluateDataset(ds Dataset) (Evaluation, error) {
+	return evaluate(net, ds)
+}
+
+// score computes the squared error of a sample, and 1 if it is well classified
+func (net Network) score(x, y []float64) (float64, float64, error) {
+	if err := net.check(x, y); err != nil {
+		return 0, 0, err
+	}
+	out := net.Predict(x)
+	var accuracy float64
+	if classify(out) == classify(y) {
+		accuracy = 1
+	}
+	return meanSquaredError(out, y), accuracy, nil
+}
+
+// Predict takes a vector of inputs and computes a vector of outputs.
+// It does not modify the network and can be called concurrently.
 func (net Network) Predict(x []float64) []float64 {
-	return net.feedForward(x)
+	return net.feedForward(net.scale(x))
+}
+
+// SetLabels attaches the class label of each output
+func (net *Network) SetLabels(labels *LabelEncoder) {
+	net.labels = labels
+}
+
+// Labels returns the class label of each output, if set
+func (net Network) Labels() *LabelEncoder {
+	return net.labels
+}
+
+// Classify predicts the k best classes of an input, highest score first (labels shall be set)
+func (net Network) Classify(x []float64, k int) ([]Prediction, error) {
+	if net.labels == nil {
+		return nil, fmt.Errorf("labels shall be set")
+	}
+	return net.labels.Decode(net.Predict(x), k)
+}
+
+// ClassifyMulti predicts all classes of an input scoring at least the threshold (labels shall be set)
+func (net Network) ClassifyMulti(x []float64, threshold float64) ([]Prediction, error) {
+	if net.labels == nil {
+		return nil, fmt.Errorf("labels shall be set")
+	}
+	return net.labels.DecodeMulti(net.Predict(x), threshold)
 }
 
 // MarshalJSON exports the whole network in a json format
 func (net Network) MarshalJSON() ([]byte, error) {
-	layers := make([]map[string]Layer, len(net.layers))
-	for i, layer := range net.layers {
-		layers[i] = map[string]Layer{
-			layer.Type(): layer,
+	var scaler map[string]Scaler
+	if net.scaler != nil {
+		scaler = map[string]Scaler{
+			net.scaler.Type(): net.scaler,
 		}
 	}
 
 	type marshal struct {
 		Rate    float64            `json:"learning-rate"`
 		Neurons []int              `json:"neurons"`
+		Scaler  map[string]Scaler  `json:"scaler,omitempty"`
+		Labels  *LabelEncoder      `json:"labels,omitempty"`
+		Frozen  []int              `json:"frozen,omitempty"`
 		Layers  []map[string]Layer `json:"layers"`
 	}
 	return json.Marshal(marshal{
 		Rate:    net.learningRate,
 		Neurons: net.neurons,
-		Layers:  layers,
+		Scaler:  scaler,
+		Labels:  net.labels,
+		Frozen:  net.frozenIndexes(),
+		Layers:  marshalLayers(net.layers),
 	})
 }
 
@@ -191,6 +456,9 @@ func (net *Network) UnmarshalJSON(data []byte) error {
 	type unmarshal struct {
 		Rate    float64                      `json:"learning-rate"`
 		Neurons []int                        `json:"neurons"`
+		Scaler  map[string]json.RawMessage   `json:"scaler"`
+		Labels  *LabelEncoder                `json:"labels"`
+		Frozen  []int                        `json:"frozen"`
 		Layers  []map[string]json.RawMessage `json:"layers"`
 	}
 	unm := unmarshal{}
@@ -202,34 +470,127 @@ func (net *Network) UnmarshalJSON(data []byte) error {
 	// Set known data
 	net.learningRate = unm.Rate
 	net.neurons = unm.Neurons
-	net.layers = make([]Layer, len(unm.Layers))
+	net.random = NewSource(1)
+	net.labels = unm.Labels
+
+	// Unmarshal scaler
+	net.scaler = nil
+	if len(unm.Scaler) > 1 {
+		return fmt.Errorf("expected only one tag in scaler")
+	}
+	for typ, data := range unm.Scaler {
+		net.scaler, err = unmarshalScaler(typ, data)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Unmarshal layers
-	for i, item := range unm.Layers {
-		if len(item) != 1 {
-			return fmt.Errorf("expected only one tag in layer")
+	net.layers, err = unmarshalLayers(unm.Layers)
+	if err != nil {
+		return err
+	}
+
+	// Check the layers against the neurons
+	if len(net.layers) > 0 {
+		if len(net.neurons) == 0 {
+			return fmt.Errorf("neurons expected with the layers")
+		}
+		out, err := outputSize(net.in(), net.layers)
+		if err != nil {
+			return err
 		}
+		if out != net.out() {
+			return fmt.Errorf("layers outputs (%d) do not match output neurons (%d)", out, net.out())
+		}
+	}
+	if net.labels != nil && (len(net.neurons) == 0 || len(net.labels.Classes()) != net.out()) {
+		return fmt.Errorf("labels (%d) do not match output neurons", len(net.labels.Classes()))
+	}
+	if err := net.checkScaler(); err != nil {
+		return err
+	}
+
+	net.frozen = nil
+	return net.Freeze(unm.Frozen...)
+}
 
-		var layer Layer
+// marshalLayers tags each layer with its type
+func marshalLayers(layers []Layer) []map[string]Layer {
+	tagged := make([]map[string]Layer, len(layers))
+	for i, layer := range layers {
+		tagged[i] = map[string]Layer{
+			layer.Type(): layer,
+		}
+	}
+	return tagged
+}
+
+// unmarshalLayers converts a list of typed json contents to layers
+func unmarshalLayers(items []map[string]json.RawMessage) ([]Layer, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	layers := make([]Layer, len(items))
+	for i, item := range items {
+		if len(item) != 1 {
+			return nil, fmt.Errorf("expected only one tag in layer")
+		}
 		for typ, data := range item { // only one item processed
-			switch typ {
-			case "linear":
-				linear := Linear{}
-				err = linear.UnmarshalJSON(data)
-				layer = linear
-			case "activator":
-				activ := activatorLayer{}
-				err = activ.UnmarshalJSON(data)
-				layer = activ
-			default:
-				err = fmt.Errorf("unknown layer type %q", typ)
-			}
+			layer, err := unmarshalLayer(typ, data)
 			if err != nil {
-				return err
+				return nil, err
 			}
+			layers[i] = layer
 		}
-		net.layers[i] = layer
 	}
+	return layers, nil
+}
 
-	return nil
+// unmarshalLayer converts a typed json content to a layer
+func unmarshalLayer(typ string, data []byte) (Layer, error) {
+	switch typ {
+	case "linear":
+		linear := Linear{}
+		err := linear.UnmarshalJSON(data)
+		return linear, err
+	case "activator":
+		activ := ActivatorLayer{}
+		err := activ.UnmarshalJSON(data)
+		return activ, err
+	case "embedding":
+		emb := Embedding{}
+		err := emb.UnmarshalJSON(data)
+		return emb, err
+	case "conv2d":
+		conv := Conv2D{}
+		err := conv.UnmarshalJSON(data)
+		return conv, err
+	case "conv1d":
+		conv := Conv1D{}
+		err := conv.UnmarshalJSON(data)
+		return conv, err
+	case "global-avg-pool1d":
+		pool := GlobalAveragePool1D{}
+		err := pool.UnmarshalJSON(data)
+		return pool, err
+	case "max-pool2d":
+		pool := MaxPool2D{}
+		err := pool.UnmarshalJSON(data)
+		return pool, err
+	case "avg-pool2d":
+		pool := AvgPool2D{}
+		err := pool.UnmarshalJSON(data)
+		return pool, err
+	case "flatten":
+		fl := Flatten{}
+		err := fl.UnmarshalJSON(data)
+		return fl, err
+	case "residual":
+		res := Residual{}
+		err := res.UnmarshalJSON(data)
+		return res, err
+	default:
+		return nil, fmt.Errorf("unknown layer type %q", typ)
+	}
 }
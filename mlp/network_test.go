@@ -1,6 +1,8 @@
 package mlp
 
 import (
+	"context"
+	"math"
 	"math/rand"
 	"testing"
 
@@ -28,6 +30,52 @@ func TestNetwork(t *testing.T) {
 
 			// Compare both
 			So(net2, ShouldResemble, net1)
+
+			// Layers not matching the neurons
+			net1.neurons = nil
+			js1, err1 = net1.MarshalJSON()
+			So(err1, ShouldBeNil)
+			So(net2.UnmarshalJSON(js1), ShouldBeError, "neurons expected with the layers")
+			net1.neurons = []int{3, 5, 6, 1}
+			js1, err1 = net1.MarshalJSON()
+			So(err1, ShouldBeNil)
+			So(net2.UnmarshalJSON(js1), ShouldBeError, "linear layer inputs (2) do not match 3 values")
+
+			// Inconsistent weights or labels
+			var ln Linear
+			So(ln.UnmarshalJSON([]byte(`{"weights":[[1,2],[3,4]],"biaises":[0]}`)), ShouldBeError, "weights row 0 (2) does not match biases (1)")
+			So(ln.UnmarshalJSON([]byte(`{"weights":[[1,2],[3]],"biaises":[0,0]}`)), ShouldBeError, "weights row 1 (1) does not match biases (2)")
+			So(net2.UnmarshalJSON([]byte(`{"neurons":[1,2],"labels":["a"],"layers":[{"linear":{"weights":[[1,2]],"biaises":[0,0]}}]}`)), ShouldBeError,
+				"labels (1) do not match output neurons")
+		})
+
+		Convey("observe epochs", func() {
+			net1 := NewNetwork(0.42, 2)
+			net1.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+			net1.Stop.OnEpoch(2)
+			var observed History
+			net1.Observe(ObserverFunc(func(m Metrics) {
+				observed = append(observed, m)
+			}))
+
+			term, err := net1.Train(context.Background(), [][]float64{{0, 1}, {1, 0}}, [][]float64{{1}, {0}})
+			So(err, ShouldBeNil)
+			So(observed, ShouldHaveLength, len(term.History()))
+			So(observed.Last().Samples, ShouldEqual, 2)
+			So(observed.Last().LearningRate, ShouldEqual, 0.42)
+		})
+
+		Convey("empty or non finite data", func() {
+			net1 := NewNetwork(0.42, 2)
+			net1.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+			net1.Stop.OnEpoch(0)
+			_, err := net1.Train(context.Background(), nil, nil)
+			So(err, ShouldBeError, "at least one sample expected")
+
+			term, err := net1.Train(context.Background(), [][]float64{{math.NaN(), 1}}, [][]float64{{1}})
+			So(err, ShouldBeNil)
+			So(term.History().Last().NonFinite, ShouldEqual, 1)
+			So(math.IsInf(term.History().Last().MeanSquaredError, 1), ShouldBeTrue)
 		})
 
 		Convey("train and cancel context", func() {
@@ -35,6 +83,11 @@ func TestNetwork(t *testing.T) {
 			net1.AddLayer(LinearBuilder{}, 5, Htan{})
 			net1.AddLayer(LinearBuilder{}, 6, ReLU{})
 			net1.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := net1.Train(ctx, [][]float64{{0, 1}}, [][]float64{{1}})
+			So(err, ShouldEqual, context.Canceled)
 		})
 	})
 }
@@ -0,0 +1,264 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// pool2D reduces each channel of an image by sliding a square window
+type pool2D struct {
+	in     Shape // Input shape
+	size   int   // Size of the square window
+	stride int   // Step between two positions of the window
+}
+
+// newPool2D checks the hyper parameters and the shapes
+func newPool2D(in Shape, size, stride int) (pool2D, error) {
+	pool := pool2D{in: in, size: size, stride: stride}
+	return pool, pool.check()
+}
+
+// check the hyper parameters and the shapes
+func (pool pool2D) check() error {
+	if err := pool.in.check(); err != nil {
+		return err
+	}
+	if pool.size <= 0 || pool.stride <= 0 {
+		return fmt.Errorf("size and stride shall be positive")
+	}
+	if pool.in.Height < pool.size || pool.in.Width < pool.size {
+		return fmt.Errorf("window %d does not fit in shape %s", pool.size, pool.in)
+	}
+	return nil
+}
+
+// InputShape returns the shape of the input image
+func (pool pool2D) InputShape() Shape {
+	return pool.in
+}
+
+// OutputShape returns the shape of the output image
+func (pool pool2D) OutputShape() Shape {
+	size := func(n int) int {
+		return (n-pool.size)/pool.stride + 1
+	}
+	return Shape{
+		Channels: pool.in.Channels,
+		Height:   size(pool.in.Height),
+		Width:    size(pool.in.Width),
+	}
+}
+
+// each calls fn with the output index and the input indexes of each window
+func (pool pool2D) each(fn func(yi int, window []int)) {
+	out := pool.OutputShape()
+	window := make([]int, pool.size*pool.size)
+	for c := 0; c < out.Channels; c++ {
+		for i := 0; i < out.Height; i++ {
+			for j := 0; j < out.Width; j++ {
+				for u := 0; u < pool.size; u++ {
+					for v := 0; v < pool.size; v++ {
+						window[u*pool.size+v] = pool.in.index(c, i*pool.stride+u, j*pool.stride+v)
+					}
+				}
+				fn(out.index(c, i, j), window)
+			}
+		}
+	}
+}
+
+// Update does nothing
+func (pool pool2D) Update(learningRate float64) {
+	// No processing
+}
+
+// summary describes the layer
+func (pool pool2D) summary(typ string, in int) LayerSummary {
+	out := pool.OutputShape()
+	return LayerSummary{
+		Type:    typ,
+		Inputs:  in,
+		Outputs: out.Size(),
+		Shape:   out.String(),
+	}
+}
+
+type exportPool2D struct {
+	Shape  Shape `json:"shape"`
+	Size   int   `json:"size"`
+	Stride int   `json:"stride"`
+}
+
+func (pool pool2D) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportPool2D{
+		Shape:  pool.in,
+		Size:   pool.size,
+		Stride: pool.stride,
+	})
+}
+
+func (pool *pool2D) UnmarshalJSON(data []byte) error {
+	exp := exportPool2D{}
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+	*pool = pool2D{in: exp.Shape, size: exp.Size, stride: exp.Stride}
+	return pool.check()
+}
+
+// MaxPool2D layer keeps the highest value of each window
+type MaxPool2D struct {
+	pool2D
+}
+
+// NewMaxPool2D builds a max pooling layer
+func NewMaxPool2D(in Shape, size, stride int) (MaxPool2D, error) {
+	pool, err := newPool2D(in, size, stride)
+	return MaxPool2D{pool}, err
+}
+
+// FeedForward keeps the highest value of each window
+func (pool MaxPool2D) FeedForward(x []float64) []float64 {
+	y := newVector(pool.OutputShape().Size())
+	pool.each(func(yi int, window []int) {
+		y[yi] = x[argMax(x, window)]
+	})
+	return y
+}
+
+// BackPropagation passes the y gradient to the highest value of each window
+func (pool MaxPool2D) BackPropagation(x, yGrad []float64) []float64 {
+	xGrad := newVector(len(x)).zeros()
+	pool.each(func(yi int, window []int) {
+		xGrad[argMax(x, window)] += yGrad[yi]
+	})
+	return xGrad
+}
+
+func (pool MaxPool2D) Type() string {
+	return "max-pool2d"
+}
+
+// summary describes the layer
+func (pool MaxPool2D) summary(in int) LayerSummary {
+	return pool.pool2D.summary(pool.Type(), in)
+}
+
+// argMax returns the index (among the given indexes) of the first highest value
+func argMax(x []float64, indexes []int) int {
+	best, max := indexes[0], math.Inf(-1)
+	for _, i := range indexes {
+		if x[i] > max {
+			best, max = i, x[i]
+		}
+	}
+	return best
+}
+
+// AvgPool2D layer computes the mean of each window
+type AvgPool2D struct {
+	pool2D
+}
+
+// NewAvgPool2D builds an average pooling layer
+func NewAvgPool2D(in Shape, size, stride int) (AvgPool2D, error) {
+	pool, err := newPool2D(in, size, stride)
+	return AvgPool2D{pool}, err
+}
+
+// FeedForward computes the mean of each window
+func (pool AvgPool2D) FeedForward(x []float64) []float64 {
+	y := newVector(pool.OutputShape().Size())
+	pool.each(func(yi int, window []int) {
+		var sum float64
+		for _, xi := range window {
+			sum += x[xi]
+		}
+		y[yi] = sum / float64(len(window))
+	})
+	return y
+}
+
+// BackPropagation shares the y gradient between the values of each window
+func (pool AvgPool2D) BackPropagation(x, yGrad []float64) []float64 {
+	xGrad := newVector(len(x)).zeros()
+	pool.each(func(yi int, window []int) {
+		for _, xi := range window {
+			xGrad[xi] += yGrad[yi] / float64(len(window))
+		}
+	})
+	return xGrad
+}
+
+func (pool AvgPool2D) Type() string {
+	return "avg-pool2d"
+}
+
+// summary describes the layer
+func (pool AvgPool2D) summary(in int) LayerSummary {
+	return pool.pool2D.summary(pool.Type(), in)
+}
+
+// Flatten layer ends the image layers: values are kept as is (already flat),
+// the following layers ignore the shape
+type Flatten struct {
+	in Shape // Input shape
+}
+
+// NewFlatten builds a flatten layer
+func NewFlatten(in Shape) (Flatten, error) {
+	return Flatten{in: in}, in.check()
+}
+
+// FeedForward keeps the values
+func (fl Flatten) FeedForward(x []float64) []float64 {
+	return x
+}
+
+// BackPropagation keeps the y gradient
+func (fl Flatten) BackPropagation(x, yGrad []float64) []float64 {
+	return yGrad
+}
+
+// Update does nothing
+func (fl Flatten) Update(learningRate float64) {
+	// No processing
+}
+
+func (fl Flatten) Type() string {
+	return "flatten"
+}
+
+// InputShape returns the shape of the input image
+func (fl Flatten) InputShape() Shape {
+	return fl.in
+}
+
+// summary describes the layer
+func (fl Flatten) summary(in int) LayerSummary {
+	return LayerSummary{
+		Type:    fl.Type(),
+		Inputs:  in,
+		Outputs: in,
+	}
+}
+
+type exportFlatten struct {
+	Shape Shape `json:"shape"`
+}
+
+func (fl Flatten) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportFlatten{Shape: fl.in})
+}
+
+func (fl *Flatten) UnmarshalJSON(data []byte) error {
+	exp := exportFlatten{}
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+	fl.in = exp.Shape
+	return fl.in.check()
+}
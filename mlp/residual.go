@@ -0,0 +1,242 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Residual layer adds its input to the output of a sequence of layers (skip connection)
+// y = f(x) + x, or y = f(x) + x.w + b with a learned projection when the sizes differ
+type Residual struct {
+	layers     []Layer // Sequence f
+	projection *Linear // Shortcut projection (nil if f keeps the size)
+}
+
+// NewResidual wraps a sequence of layers receiving in values
+func NewResidual(in int, layers ...Layer) (Residual, error) {
+	if len(layers) == 0 {
+		return Residual{}, fmt.Errorf("at least one layer expected")
+	}
+	out, err := outputSize(in, layers)
+	if err != nil {
+		return Residual{}, err
+	}
+
+	res := Residual{layers: layers}
+	if out != in {
+		projection := NewLinear(in, out)
+		res.projection = &projection
+	}
+	return res, nil
+}
+
+// check that the layers receive in values and that the shortcut matches their output, returns the output size
+func (res Residual) check(in int) (int, error) {
+	out, err := outputSize(in, res.layers)
+	if err != nil {
+		return 0, err
+	}
+	if res.projection == nil {
+		if out != in {
+			return 0, fmt.Errorf("residual layer outputs (%d) do not match %d values without projection", out, in)
+		}
+		return out, nil
+	}
+	if rows, cols := res.projection.Shape(); rows != in || cols != out {
+		return 0, fmt.Errorf("residual projection (%dx%d) does not match %d values and %d outputs", rows, cols, in, out)
+	}
+	return out, nil
+}
+
+// FeedForward adds the input (or its projection) to the output of the layers
+func (res Residual) FeedForward(x []float64) []float64 {
+	fx := x
+	for _, layer := range res.layers {
+		fx = layer.FeedForward(fx)
+	}
+	shortcut := x
+	if res.projection != nil {
+		shortcut = res.projection.FeedForward(x)
+	}
+
+	y := newVector(len(fx))
+	return y.iter(func(j int) {
+		y[j] = fx[j] + shortcut[j]
+	})
+}
+
+// BackPropagation computes the x gradient through both paths.
+// The inputs of the inner layers are computed again, so that FeedForward keeps no state.
+func (res Residual) BackPropagation(x, yGrad []float64) []float64 {
+	inputs := make([][]float64, len(res.layers))
+	io := x
+	for i, layer := range res.layers {
+		inputs[i] = io
+		io = layer.FeedForward(io)
+	}
+
+	// gradient x = gradient f + gradient shortcut
+	grad := yGrad
+	for i := len(res.layers) - 1; i >= 0; i-- {
+		grad = res.layers[i].BackPropagation(inputs[i], grad)
+	}
+	shortcutGrad := yGrad
+	if res.projection != nil {
+		shortcutGrad = res.projection.BackPropagation(x, yGrad)
+	}
+
+	xGrad := newVector(len(x))
+	return xGrad.iter(func(i int) {
+		xGrad[i] = grad[i] + shortcutGrad[i]
+	})
+}
+
+// Update the inner layers and the projection
+func (res Residual) Update(learningRate float64) {
+	for _, layer := range res.layers {
+		layer.Update(learningRate)
+	}
+	if res.projection != nil {
+		res.projection.Update(learningRate)
+	}
+}
+
+// gradientNorm2 sums the squared gradients of the inner layers and of the projection
+func (res Residual) gradientNorm2() float64 {
+	var sum float64
+	for _, layer := range res.layers {
+		if trainable, ok := layer.(Trainable); ok {
+			sum += gradientNorm2(trainable)
+		}
+	}
+	if res.projection != nil {
+		sum += gradientNorm2(res.projection)
+	}
+	return sum
+}
+
+// Parameters returns the parameters of the inner layers and of the projection
+func (res Residual) Parameters() []Parameter {
+	var params []Parameter
+	for i, layer := range res.layers {
+		if trainable, ok := layer.(Trainable); ok {
+			for _, param := range trainable.Parameters() {
+				param.Name = fmt.Sprintf("layers[%d].%s", i, param.Name)
+				params = append(params, param)
+			}
+		}
+	}
+	if res.projection != nil {
+		for _, param := range res.projection.Parameters() {
+			param.Name = "projection." + param.Name
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+func (res Residual) Type() string {
+	return "residual"
+}
+
+// Layers returns the inner layers
+func (res Residual) Layers() []Layer {
+	return append([]Layer(nil), res.layers...)
+}
+
+// Projection returns the shortcut projection, if any
+func (res Residual) Projection() (Linear, bool) {
+	if res.projection == nil {
+		return Linear{}, false
+	}
+	return *res.projection, true
+}
+
+// summary describes the layer
+func (res Residual) summary(in int) LayerSummary {
+	sum := LayerSummary{
+		Type:    res.Type(),
+		Inputs:  in,
+		Outputs: in,
+	}
+	for _, layer := range res.layers {
+		if s, ok := layer.(summarizer); ok {
+			ls := s.summary(sum.Outputs)
+			sum.Outputs = ls.Outputs
+			sum.Parameters += ls.Parameters
+		}
+	}
+	if res.projection != nil {
+		sum.Parameters += res.projection.summary(in).Parameters
+	}
+	return sum
+}
+
+type exportResidual struct {
+	Layers     []map[string]Layer `json:"layers"`
+	Projection *Linear            `json:"projection,omitempty"`
+}
+
+func (res Residual) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportResidual{
+		Layers:     marshalLayers(res.layers),
+		Projection: res.projection,
+	})
+}
+
+func (res *Residual) UnmarshalJSON(data []byte) error {
+	type unmarshal struct {
+		Layers     []map[string]json.RawMessage `json:"layers"`
+		Projection *Linear                      `json:"projection"`
+	}
+	unm := unmarshal{}
+	err := json.Unmarshal(data, &unm)
+	if err != nil {
+		return err
+	}
+
+	res.layers, err = unmarshalLayers(unm.Layers)
+	if err != nil {
+		return err
+	}
+	if len(res.layers) == 0 {
+		return fmt.Errorf("at least one layer expected")
+	}
+	res.projection = unm.Projection
+
+	// The projection gives the input size, otherwise it is checked when the layer is added to a network
+	if res.projection != nil {
+		rows, _ := res.projection.Shape()
+		_, err = res.check(rows)
+	}
+	return err
+}
+
+// ResidualBuilder builds residual blocks y = f(x) + x,
+// where f is a sequence of layers separated by an activator.
+// The activator given to AddLayer is applied after the sum (ReLU keeps the gradients of deep stacks).
+type ResidualBuilder struct {
+	Builder   LayerBuilder // Inner layers (default: LinearBuilder)
+	Activator Activator    // Activator between inner layers (default: ReLU)
+	Depth     int          // Number of inner layers (default: 2)
+}
+
+func (bld ResidualBuilder) New(in, out int) Layer {
+	inner, act, depth := bld.Builder, bld.Activator, bld.Depth
+	if inner == nil {
+		inner = LinearBuilder{}
+	}
+	if act == nil {
+		act = ReLU{}
+	}
+	if depth <= 0 {
+		depth = 2
+	}
+
+	layers := []Layer{inner.New(in, out)}
+	for d := 1; d < depth; d++ {
+		layers = append(layers, NewActivatorLayer(act), inner.New(out, out))
+	}
+	res, _ := NewResidual(in, layers...) // sizes are consistent
+	return res
+}
@@ -0,0 +1,108 @@
+package mlp
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResidual(t *testing.T) {
+	Convey("residual", t, func() {
+		rand.Seed(42)
+		x := []float64{0.5, -0.3, 0.8}
+
+		Convey("identity shortcut", func() {
+			zero, err := NewLinearWeights([][]float64{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}}, []float64{0, 0, 0})
+			So(err, ShouldBeNil)
+			res, err := NewResidual(3, zero, NewActivatorLayer(Htan{}))
+			So(err, ShouldBeNil)
+			_, ok := res.Projection()
+			So(ok, ShouldBeFalse)
+			So(res.FeedForward(x), ShouldResemble, []float64{0.5, -0.3, 0.8})
+			So(res.BackPropagation(x, []float64{1, 2, 3}), ShouldResemble, []float64{1, 2, 3})
+			So(res.summary(3), ShouldResemble, LayerSummary{Type: "residual", Inputs: 3, Outputs: 3, Parameters: 12})
+		})
+
+		Convey("gradients with and without projection", func() {
+			for _, out := range []int{3, 2} {
+				res := ResidualBuilder{Activator: Sigmoid{}, Depth: 3}.New(3, out).(Residual)
+				So(res.Layers(), ShouldHaveLength, 5)
+				_, ok := res.Projection()
+				So(ok, ShouldEqual, out != 3)
+
+				target := make([]float64, out)
+				result, err := GradCheck(x, target, 1e-6, res, NewActivatorLayer(Htan{}))
+				So(err, ShouldBeNil)
+				So(result.MaxError().Error, ShouldBeLessThan, 1e-6)
+				So(result.Parameters, ShouldHaveLength, res.summary(3).Parameters)
+			}
+			res := ResidualBuilder{}.New(3, 2).(Residual)
+			So(res.Parameters()[0].Name, ShouldEqual, "layers[0].biases")
+			So(res.Parameters()[len(res.Parameters())-1].Name, ShouldEqual, "projection.weights[2]")
+		})
+
+		Convey("deep sigmoid stack keeps gradients", func() {
+			// Norm of the gradients of the first layer after one sample
+			firstGrad := func(bld LayerBuilder, act Activator) float64 {
+				net := NewNetwork(0.1, 4)
+				for i := 0; i < 12; i++ {
+					net.AddLayer(bld, 4, act)
+				}
+				y := net.feedForward([]float64{0.1, 0.2, 0.3, 0.4})
+				net.backPropagation([]float64{y[0] - 1, y[1], y[2], y[3]})
+
+				var sum float64
+				for _, param := range net.layers[0].(Trainable).Parameters() {
+					for _, grad := range param.Grads {
+						sum += grad * grad
+					}
+				}
+				return math.Sqrt(sum)
+			}
+
+			plain := firstGrad(LinearBuilder{}, Sigmoid{})
+			residual := firstGrad(ResidualBuilder{Activator: Sigmoid{}, Depth: 2}, ReLU{})
+			So(residual, ShouldBeGreaterThan, 100*plain)
+		})
+
+		Convey("network json and summary", func() {
+			net := NewNetwork(0.1, 3)
+			net.AddLayer(ResidualBuilder{}, 3, ReLU{})
+			net.AddLayer(ResidualBuilder{Depth: 1}, 2, Sigmoid{})
+			So(net.Neurons(), ShouldResemble, []int{3, 3, 2})
+
+			sum := net.Summary()
+			So(sum.Layers[0], ShouldResemble, LayerSummary{Type: "residual", Inputs: 3, Outputs: 3, Parameters: 24})
+			So(sum.Layers[2], ShouldResemble, LayerSummary{Type: "residual", Inputs: 3, Outputs: 2, Parameters: 16})
+
+			data, err := json.Marshal(net)
+			So(err, ShouldBeNil)
+			var net2 Network
+			So(json.Unmarshal(data, &net2), ShouldBeNil)
+			So(net2.layers, ShouldResemble, net.layers)
+			So(net2.Predict(x), ShouldResemble, net.Predict(x))
+
+			var res Residual
+			So(res.UnmarshalJSON([]byte(`{"layers":[]}`)), ShouldBeError, "at least one layer expected")
+			_, err = NewResidual(2, NewLinear(3, 2))
+			So(err, ShouldBeError, "linear layer inputs (3) do not match 2 values")
+
+			// Residual blocks not matching the network or their shortcut
+			res, err = NewResidual(3, NewLinear(3, 3))
+			So(err, ShouldBeNil)
+			So(net.Add(res), ShouldBeError, "linear layer inputs (3) do not match 2 values")
+			data, err = json.Marshal(NewLinear(2, 3))
+			So(err, ShouldBeNil)
+			inner := `{"layers":[{"linear":` + string(data) + `}]`
+			projection, err := json.Marshal(NewLinear(2, 2))
+			So(err, ShouldBeNil)
+			So(res.UnmarshalJSON([]byte(inner+`,"projection":`+string(projection)+`}`)), ShouldBeError,
+				"residual projection (2x2) does not match 2 values and 3 outputs")
+			So(json.Unmarshal([]byte(`{"neurons":[2,3],"layers":[{"residual":`+inner+`}}]}`), &net2), ShouldBeError,
+				"residual layer outputs (3) do not match 2 values without projection")
+		})
+	})
+}
@@ -0,0 +1,200 @@
+package mlp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"time"
+)
+
+// Messages of the training events
+const (
+	msgStart      = "training started"
+	msgEpoch      = "epoch"
+	msgCheckpoint = "checkpoint saved"
+	msgStop       = "training stopped"
+	msgFailure    = "training failed"
+)
+
+// SetLogger sets the logger of the training events (nil: no logs):
+// start with the hyper parameters, metrics of each epoch, checkpoints and stop reason
+func (net *Network) SetLogger(logger *slog.Logger) {
+	net.logger = logger
+}
+
+// NewRunLogger builds a logger writing json lines, that can be read again with ReadRunLog
+func NewRunLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, nil))
+}
+
+// logStart logs the hyper parameters
+func (net Network) logStart(epoch int) {
+	if net.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.Int("epoch", epoch),
+		slog.Any("neurons", net.neurons),
+		slog.Float64("learning-rate", net.learningRate),
+		slog.Int("parameters", net.Summary().Parameters),
+		slog.String("stop", joinCriteria(net.Stop.criteria, ", ")),
+	}
+	if net.scaler != nil {
+		attrs = append(attrs, slog.String("scaler", net.scaler.Type()))
+	}
+	net.logger.LogAttrs(context.Background(), slog.LevelInfo, msgStart, attrs...)
+}
+
+// logEpoch logs the metrics of an epoch, non finite values are skipped
+func (net Network) logEpoch(m Metrics) {
+	if net.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.Int("epoch", m.Epoch),
+		slog.Duration("duration", m.Duration),
+		slog.Duration("epoch-duration", m.EpochDuration),
+		slog.Int("samples", m.Samples),
+		slog.Float64("learning-rate", m.LearningRate),
+		slog.Int("non-finite", m.NonFinite),
+	}
+	values := []struct {
+		key   string
+		value float64
+	}{
+		{"error", m.MeanSquaredError},
+		{"gradient-norm", m.GradientNorm},
+		{"validation-error", m.ValidationError},
+		{"validation-accuracy", m.ValidationAccuracy},
+	}
+	for _, v := range values {
+		if !math.IsNaN(v.value) && !math.IsInf(v.value, 0) {
+			attrs = append(attrs, slog.Float64(v.key, v.value))
+		}
+	}
+	net.logger.LogAttrs(context.Background(), slog.LevelInfo, msgEpoch, attrs...)
+}
+
+// logCheckpoint logs a checkpoint saving
+func (net Network) logCheckpoint(epoch int) {
+	if net.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.Int("epoch", epoch)}
+	if net.Checkpoint.dir != "" {
+		attrs = append(attrs, slog.String("path", filepath.Join(net.Checkpoint.dir, fmt.Sprintf(checkpointPattern, epoch))))
+	}
+	net.logger.LogAttrs(context.Background(), slog.LevelInfo, msgCheckpoint, attrs...)
+}
+
+// logStop logs the stop reason or the error
+func (net Network) logStop(term Termination, err error) {
+	if net.logger == nil {
+		return
+	}
+	epochs := slog.Int("epochs", len(term.history))
+	if err != nil {
+		net.logger.LogAttrs(context.Background(), slog.LevelError, msgFailure, epochs, slog.String("error", err.Error()))
+		return
+	}
+	net.logger.LogAttrs(context.Background(), slog.LevelInfo, msgStop, epochs, slog.String("reached", term.reached.String()))
+}
+
+// RunLog is the content of a json lines training log
+type RunLog struct {
+	Parameters  map[string]interface{} // Hyper parameters logged at start
+	History     History                // Metrics of each epoch
+	Checkpoints []int                  // Epochs saved
+	Reached     string                 // Criterion that stopped the training
+	Error       string                 // Training failure
+}
+
+// logRecord is one line of the run log
+type logRecord struct {
+	Msg                string          `json:"msg"`
+	Epoch              int             `json:"epoch"`
+	Duration           time.Duration   `json:"duration"`
+	EpochDuration      time.Duration   `json:"epoch-duration"`
+	Samples            int             `json:"samples"`
+	LearningRate       float64         `json:"learning-rate"`
+	NonFinite          int             `json:"non-finite"`
+	Error              json.RawMessage `json:"error"` // float for epochs, string for failures
+	GradientNorm       *float64        `json:"gradient-norm"`
+	ValidationError    *float64        `json:"validation-error"`
+	ValidationAccuracy *float64        `json:"validation-accuracy"`
+	Reached            string          `json:"reached"`
+}
+
+// ReadRunLog reads the json lines written by a NewRunLogger logger.
+// Resumed trainings logged in the same file are merged.
+func ReadRunLog(r io.Reader) (RunLog, error) {
+	var run RunLog
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return RunLog{}, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch rec.Msg {
+		case msgStart:
+			var params map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &params); err != nil {
+				return RunLog{}, fmt.Errorf("line %d: %w", line, err)
+			}
+			for _, key := range []string{"time", "level", "msg", "epoch"} {
+				delete(params, key)
+			}
+			run.Parameters = params
+			run.Reached, run.Error = "", ""
+			run.History = historyBefore(run.History, rec.Epoch)
+		case msgEpoch:
+			var mse *float64
+			if len(rec.Error) > 0 {
+				if err := json.Unmarshal(rec.Error, &mse); err != nil {
+					return RunLog{}, fmt.Errorf("line %d: %w", line, err)
+				}
+			}
+			run.History = append(run.History, Metrics{
+				Epoch:              rec.Epoch,
+				Duration:           rec.Duration,
+				EpochDuration:      rec.EpochDuration,
+				MeanSquaredError:   orNaN(mse),
+				GradientNorm:       orNaN(rec.GradientNorm),
+				NonFinite:          rec.NonFinite,
+				ValidationError:    orNaN(rec.ValidationError),
+				ValidationAccuracy: orNaN(rec.ValidationAccuracy),
+				Samples:            rec.Samples,
+				LearningRate:       rec.LearningRate,
+			})
+		case msgCheckpoint:
+			run.Checkpoints = append(run.Checkpoints, rec.Epoch)
+		case msgStop:
+			run.Reached = rec.Reached
+		case msgFailure:
+			if err := json.Unmarshal(rec.Error, &run.Error); err != nil {
+				return RunLog{}, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+	return run, scanner.Err()
+}
+
+// historyBefore keeps the metrics of the epochs before a resumed training
+func historyBefore(history History, epoch int) History {
+	for i, m := range history {
+		if m.Epoch >= epoch {
+			return history[:i]
+		}
+	}
+	return history
+}
@@ -0,0 +1,86 @@
+package mlp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRunLog(t *testing.T) {
+	Convey("run log", t, func() {
+		xData := [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+		yData := [][]float64{{0}, {1}, {1}, {0}}
+		net := NewNetwork(0.3, 2)
+		net.AddLayer(LinearBuilder{}, 3, Sigmoid{})
+		net.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+		So(net.SetScaler(Normalize{}), ShouldBeNil)
+
+		var logs bytes.Buffer
+		net.SetLogger(NewRunLogger(&logs))
+
+		Convey("train and read", func() {
+			net.SetValidation(xData, yData)
+			net.Stop.OnEpoch(3)
+			net.Checkpoint.Every(2)
+			net.Checkpoint.ToWriter(func(epoch int) (io.WriteCloser, error) {
+				return nopCloser{&bytes.Buffer{}}, nil
+			})
+			term, err := net.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+			So(strings.Count(logs.String(), "\n"), ShouldEqual, 1+4+2+1)
+
+			run, err := ReadRunLog(&logs)
+			So(err, ShouldBeNil)
+			So(run.Parameters["neurons"], ShouldResemble, []interface{}{2.0, 3.0, 1.0})
+			So(run.Parameters["learning-rate"], ShouldEqual, 0.3)
+			So(run.Parameters["parameters"], ShouldEqual, 13)
+			So(run.Parameters["scaler"], ShouldEqual, "normalize")
+			So(run.Parameters["stop"], ShouldEqual, "epoch >= 3")
+			So(run.History, ShouldResemble, term.History())
+			So(run.Checkpoints, ShouldResemble, []int{1, 3})
+			So(run.Reached, ShouldEqual, "epoch >= 3")
+			So(run.Error, ShouldBeEmpty)
+		})
+
+		Convey("non finite values and failure", func() {
+			net.Stop.OnEpoch(0)
+			_, err := net.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err = net.Train(ctx, xData, yData)
+			So(err, ShouldEqual, context.Canceled)
+
+			run, err := ReadRunLog(&logs)
+			So(err, ShouldBeNil)
+			So(run.History, ShouldBeEmpty) // second run started at epoch 0
+			So(run.Reached, ShouldBeEmpty)
+			So(run.Error, ShouldEqual, "context canceled")
+
+			empty := Network{}
+			empty.SetLogger(NewRunLogger(&logs))
+			_, err = empty.Train(context.Background(), xData, yData)
+			So(err, ShouldBeError, "at least one layer expected")
+		})
+
+		Convey("resumed history", func() {
+			history := History{{Epoch: 0}, {Epoch: 1}, {Epoch: 2}}
+			So(historyBefore(history, 1), ShouldResemble, history[:1])
+			So(historyBefore(history, 3), ShouldResemble, history)
+
+			run, err := ReadRunLog(strings.NewReader(`{"msg":"epoch","epoch":0,"error":null}` + "\n\n"))
+			So(err, ShouldBeNil)
+			So(math.IsNaN(run.History[0].MeanSquaredError), ShouldBeTrue)
+
+			_, err = ReadRunLog(strings.NewReader("{}\nnot json\n"))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "line 2: ")
+		})
+	})
+}
@@ -0,0 +1,331 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Scaler transforms the raw inputs before they are fed to the network
+type Scaler interface {
+	Fit(xData [][]float64) error
+	Fitted() bool
+	Transform(x []float64) []float64
+	Type() string
+}
+
+// Standardize centers and reduces each feature
+// y = (x - mean) / std
+type Standardize struct {
+	mean vector
+	std  vector
+}
+
+// Fit computes the mean and the standard deviation of each feature
+func (s *Standardize) Fit(xData [][]float64) error {
+	mean, err := columnsMean(xData)
+	if err != nil {
+		return err
+	}
+	std := newVector(len(mean)).zeros()
+	for _, x := range xData {
+		std.iter(func(j int) {
+			std[j] += (x[j] - mean[j]) * (x[j] - mean[j])
+		})
+	}
+	std.iter(func(j int) {
+		std[j] = nonZero(math.Sqrt(std[j] / float64(len(xData))))
+	})
+
+	s.mean, s.std = mean, std
+	return nil
+}
+
+// Fitted checks if parameters are computed
+func (s *Standardize) Fitted() bool {
+	return s.mean != nil
+}
+
+// Transform standardizes each feature
+func (s *Standardize) Transform(x []float64) []float64 {
+	y := newVector(len(x))
+	return y.iter(func(j int) {
+		y[j] = (x[j] - s.mean[j]) / s.std[j]
+	})
+}
+
+func (s *Standardize) Type() string {
+	return "standardize"
+}
+
+// MinMax scales each feature to a range (default [0, 1])
+// y = low + (x - min) * (high - low) / (max - min)
+type MinMax struct {
+	low, high float64
+	min, max  vector
+}
+
+// NewMinMax builds a scaler to the [low, high] range
+func NewMinMax(low, high float64) *MinMax {
+	return &MinMax{
+		low:  low,
+		high: high,
+	}
+}
+
+// Fit computes the min and the max of each feature
+func (s *MinMax) Fit(xData [][]float64) error {
+	if len(xData) == 0 {
+		return fmt.Errorf("at least one sample expected")
+	}
+	if s.low == 0 && s.high == 0 {
+		s.high = 1
+	}
+
+	min := newVector(len(xData[0]))
+	max := newVector(len(xData[0]))
+	copy(min, xData[0])
+	copy(max, xData[0])
+	for _, x := range xData {
+		if len(x) != len(min) {
+			return fmt.Errorf("all samples shall have the same length")
+		}
+		min.iter(func(j int) {
+			min[j] = math.Min(min[j], x[j])
+			max[j] = math.Max(max[j], x[j])
+		})
+	}
+
+	s.min, s.max = min, max
+	return nil
+}
+
+// Fitted checks if parameters are computed
+func (s *MinMax) Fitted() bool {
+	return s.min != nil
+}
+
+// Transform scales each feature
+func (s *MinMax) Transform(x []float64) []float64 {
+	y := newVector(len(x))
+	return y.iter(func(j int) {
+		y[j] = s.low + (x[j]-s.min[j])*(s.high-s.low)/nonZero(s.max[j]-s.min[j])
+	})
+}
+
+func (s *MinMax) Type() string {
+	return "min-max"
+}
+
+// Robust centers each feature on the median and scales it with the interquartile range
+// y = (x - median) / (q3 - q1)
+type Robust struct {
+	median vector
+	iqr    vector
+}
+
+// Fit computes the median and the interquartile range of each feature
+func (s *Robust) Fit(xData [][]float64) error {
+	mean, err := columnsMean(xData) // only check data
+	if err != nil {
+		return err
+	}
+
+	median := newVector(len(mean))
+	iqr := newVector(len(mean))
+	column := make([]float64, len(xData))
+	median.iter(func(j int) {
+		for i, x := range xData {
+			column[i] = x[j]
+		}
+		sort.Float64s(column)
+		median[j] = quantile(column, 0.5)
+		iqr[j] = nonZero(quantile(column, 0.75) - quantile(column, 0.25))
+	})
+
+	s.median, s.iqr = median, iqr
+	return nil
+}
+
+// Fitted checks if parameters are computed
+func (s *Robust) Fitted() bool {
+	return s.median != nil
+}
+
+// Transform scales each feature
+func (s *Robust) Transform(x []float64) []float64 {
+	y := newVector(len(x))
+	return y.iter(func(j int) {
+		y[j] = (x[j] - s.median[j]) / s.iqr[j]
+	})
+}
+
+func (s *Robust) Type() string {
+	return "robust"
+}
+
+// Normalize scales each sample to a unit euclidean norm
+// y = x / ||x||
+type Normalize struct{}
+
+// Fit does nothing
+func (s Normalize) Fit(xData [][]float64) error {
+	return nil
+}
+
+// Fitted is always true
+func (s Normalize) Fitted() bool {
+	return true
+}
+
+// Transform divides the sample by its norm
+func (s Normalize) Transform(x []float64) []float64 {
+	var norm float64
+	for _, xj := range x {
+		norm += xj * xj
+	}
+	norm = nonZero(math.Sqrt(norm))
+
+	y := newVector(len(x))
+	return y.iter(func(j int) {
+		y[j] = x[j] / norm
+	})
+}
+
+func (s Normalize) Type() string {
+	return "normalize"
+}
+
+// columnsMean computes the mean of each feature
+func columnsMean(xData [][]float64) (vector, error) {
+	if len(xData) == 0 {
+		return nil, fmt.Errorf("at least one sample expected")
+	}
+
+	mean := newVector(len(xData[0])).zeros()
+	for _, x := range xData {
+		if len(x) != len(mean) {
+			return nil, fmt.Errorf("all samples shall have the same length")
+		}
+		mean.iter(func(j int) {
+			mean[j] += x[j]
+		})
+	}
+	return mean.iter(func(j int) {
+		mean[j] /= float64(len(xData))
+	}), nil
+}
+
+// quantile of sorted values (linear interpolation)
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	i := int(pos)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// nonZero replaces a null scale by 1
+func nonZero(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return x
+}
+
+// featureScaler is a scaler fitted with one center and one scale per feature
+type featureScaler interface {
+	features() (int, error) // Number of features of the fitted parameters
+}
+
+func (s *Standardize) features() (int, error) {
+	return features(s.mean, s.std)
+}
+
+func (s *MinMax) features() (int, error) {
+	return features(s.min, s.max)
+}
+
+func (s *Robust) features() (int, error) {
+	return features(s.median, s.iqr)
+}
+
+// features checks that the centers and the scales have the same size
+func features(center, scale vector) (int, error) {
+	if len(center) != len(scale) {
+		return 0, fmt.Errorf("scaler centers (%d) do not match scales (%d)", len(center), len(scale))
+	}
+	return len(center), nil
+}
+
+// for marshal/unmarshal a scaler
+type exportScaler struct {
+	Low    float64 `json:"low,omitempty"`
+	High   float64 `json:"high,omitempty"`
+	Center vector  `json:"center,omitempty"`
+	Scale  vector  `json:"scale,omitempty"`
+}
+
+func (s *Standardize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportScaler{Center: s.mean, Scale: s.std})
+}
+
+func (s *Standardize) UnmarshalJSON(data []byte) error {
+	var exp exportScaler
+	err := json.Unmarshal(data, &exp)
+	s.mean, s.std = exp.Center, exp.Scale
+	return err
+}
+
+func (s *MinMax) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportScaler{Low: s.low, High: s.high, Center: s.min, Scale: s.max})
+}
+
+func (s *MinMax) UnmarshalJSON(data []byte) error {
+	var exp exportScaler
+	err := json.Unmarshal(data, &exp)
+	s.low, s.high, s.min, s.max = exp.Low, exp.High, exp.Center, exp.Scale
+	return err
+}
+
+func (s *Robust) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportScaler{Center: s.median, Scale: s.iqr})
+}
+
+func (s *Robust) UnmarshalJSON(data []byte) error {
+	var exp exportScaler
+	err := json.Unmarshal(data, &exp)
+	s.median, s.iqr = exp.Center, exp.Scale
+	return err
+}
+
+func (s Normalize) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportScaler{})
+}
+
+// unmarshalScaler converts a typed json content to a scaler
+func unmarshalScaler(typ string, data []byte) (Scaler, error) {
+	var scaler Scaler
+	switch typ {
+	case "standardize":
+		scaler = &Standardize{}
+	case "min-max":
+		scaler = &MinMax{}
+	case "robust":
+		scaler = &Robust{}
+	case "normalize":
+		return Normalize{}, nil
+	default:
+		return nil, fmt.Errorf("unknown scaler type %q", typ)
+	}
+	err := json.Unmarshal(data, scaler)
+	if err == nil && !scaler.Fitted() {
+		err = fmt.Errorf("scaler %q is not fitted", typ)
+	}
+	if err == nil {
+		_, err = scaler.(featureScaler).features()
+	}
+	return scaler, err
+}
@@ -0,0 +1,107 @@
+package mlp
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScaler(t *testing.T) {
+	Convey("scaler", t, func() {
+		xData := [][]float64{
+			{1, 10, 5},
+			{2, 20, 5},
+			{3, 30, 5},
+			{4, 100, 5},
+		}
+
+		Convey("standardize", func() {
+			s := &Standardize{}
+			So(s.Fitted(), ShouldBeFalse)
+			So(s.Fit(xData), ShouldBeNil)
+			So(s.Fitted(), ShouldBeTrue)
+			y := s.Transform([]float64{2.5, 40, 6})
+			So(y[0], ShouldEqual, 0)
+			So(y[1], ShouldEqual, 0)
+			So(y[2], ShouldEqual, 1) // null deviation
+			So(s.Transform([]float64{2.5 + math.Sqrt(1.25), 40, 5})[0], ShouldAlmostEqual, 1)
+		})
+
+		Convey("min max", func() {
+			s := NewMinMax(-1, 1)
+			So(s.Fit(xData), ShouldBeNil)
+			So(s.Transform([]float64{1, 100, 5}), ShouldResemble, []float64{-1, 1, -1})
+			So(s.Transform([]float64{2.5, 55, 6}), ShouldResemble, []float64{0, 0, 1})
+
+			s = &MinMax{}
+			So(s.Fit(xData), ShouldBeNil)
+			So(s.Transform([]float64{4, 10, 5}), ShouldResemble, []float64{1, 0, 0})
+		})
+
+		Convey("robust", func() {
+			s := &Robust{}
+			So(s.Fit(xData), ShouldBeNil)
+			// median = 2.5, 25, 5 ; iqr = 1.5, 30, 0
+			So(s.Transform([]float64{4, 55, 5}), ShouldResemble, []float64{1, 1, 0})
+		})
+
+		Convey("normalize", func() {
+			So(Normalize{}.Transform([]float64{3, 4}), ShouldResemble, []float64{0.6, 0.8})
+			So(Normalize{}.Transform([]float64{0, 0}), ShouldResemble, []float64{0, 0})
+		})
+
+		Convey("errors", func() {
+			So((&Standardize{}).Fit(nil), ShouldBeError, "at least one sample expected")
+			So(NewMinMax(0, 1).Fit([][]float64{{1, 2}, {1}}), ShouldBeError, "all samples shall have the same length")
+		})
+
+		Convey("attached to a network", func() {
+			rand.Seed(42)
+			net1 := NewNetwork(0.3, 3)
+			net1.AddLayer(LinearBuilder{}, 2, Sigmoid{})
+			net1.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+			So(net1.SetScaler(&Standardize{}), ShouldBeNil)
+
+			yData := [][]float64{{0}, {0}, {1}, {1}}
+			_, err := net1.Train(context.Background(), xData, yData)
+			So(err, ShouldBeNil)
+			So(net1.scaler.Fitted(), ShouldBeTrue)
+
+			// Saved in the model
+			js, err := net1.MarshalJSON()
+			So(err, ShouldBeNil)
+			So(string(js), ShouldContainSubstring, `"scaler":{"standardize":{"center":[2.5,40,5]`)
+			net2 := Network{}
+			So(net2.UnmarshalJSON(js), ShouldBeNil)
+			So(net2, ShouldResemble, net1)
+			So(net2.Predict(xData[3]), ShouldResemble, net1.Predict(xData[3]))
+
+			// Unfitted scaler
+			So(net2.UnmarshalJSON([]byte(`{"scaler": {"robust": {}}, "layers": []}`)), ShouldBeError,
+				`scaler "robust" is not fitted`)
+			So(net2.UnmarshalJSON([]byte(`{"scaler": {"robust": {"center": [1, 2], "scale": [1]}}, "layers": []}`)), ShouldBeError,
+				"scaler centers (2) do not match scales (1)")
+			So(net2.UnmarshalJSON([]byte(`{"neurons": [2], "scaler": {"robust": {"center": [1, 2, 3], "scale": [1, 1, 1]}}, "layers": []}`)), ShouldBeError,
+				`scaler "robust" features (3) do not match input neurons (2)`)
+		})
+
+		Convey("width checked against the inputs", func() {
+			net := NewNetwork(0.3, 2)
+			net.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+
+			// Attached
+			fitted := &Standardize{}
+			So(fitted.Fit(xData), ShouldBeNil)
+			So(net.SetScaler(fitted), ShouldBeError, `scaler "standardize" features (3) do not match input neurons (2)`)
+			So(net.Scaler(), ShouldBeNil)
+
+			// Fitted
+			So(net.SetScaler(&Standardize{}), ShouldBeNil)
+			_, err := net.Train(context.Background(), xData, [][]float64{{0}, {0}, {1}, {1}})
+			So(err, ShouldBeError, `scaler "standardize" features (3) do not match input neurons (2)`)
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package mlp
+
+// Source is a random source (splitmix64) whose state can be saved and restored
+// It implements rand.Source64
+type Source struct {
+	state uint64
+}
+
+// NewSource builds a new source initialized with the seed
+func NewSource(seed int64) *Source {
+	src := &Source{}
+	src.Seed(seed)
+	return src
+}
+
+// Seed resets the state of the source
+func (src *Source) Seed(seed int64) {
+	src.state = uint64(seed)
+}
+
+// Uint64 returns a pseudo random 64 bits value
+func (src *Source) Uint64() uint64 {
+	src.state += 0x9e3779b97f4a7c15
+	z := src.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns a pseudo random 63 bits value
+func (src *Source) Int63() int64 {
+	return int64(src.Uint64() >> 1)
+}
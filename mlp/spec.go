@@ -0,0 +1,218 @@
+package mlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Spec is a declarative description of a network
+type Spec struct {
+	Inputs    int           `json:"inputs"`
+	Layers    []LayerSpec   `json:"layers"`
+	Optimizer OptimizerSpec `json:"optimizer"`
+	Loss      string        `json:"loss,omitempty"` // "mse" (default)
+	Stop      StopSpec      `json:"stop"`
+}
+
+// LayerSpec describes a layer followed by its activator
+type LayerSpec struct {
+	Type           string          `json:"type,omitempty"` // "linear" (default)
+	Neurons        int             `json:"neurons"`
+	Activator      string          `json:"activator"`
+	Initializer    string          `json:"initializer,omitempty"` // "normal" (default), "xavier", "he"
+	Regularization *Regularization `json:"regularization,omitempty"`
+}
+
+// OptimizerSpec describes how weights are updated
+type OptimizerSpec struct {
+	Type         string  `json:"type,omitempty"` // "sgd" (default)
+	LearningRate float64 `json:"learning-rate"`
+}
+
+// StopSpec describes the stop criteria, the first reached stops the training
+type StopSpec struct {
+	Epoch              *int         `json:"epoch,omitempty"`
+	Duration           string       `json:"duration,omitempty"`
+	Error              *float64     `json:"error,omitempty"`
+	ValidationAccuracy *float64     `json:"validation-accuracy,omitempty"`
+	GradientNorm       *float64     `json:"gradient-norm,omitempty"`
+	NonFinite          *int         `json:"non-finite,omitempty"`
+	Plateau            *PlateauSpec `json:"plateau,omitempty"`
+}
+
+// PlateauSpec describes a plateau criterion
+type PlateauSpec struct {
+	Patience int     `json:"patience"`
+	Delta    float64 `json:"delta"`
+}
+
+// ReadSpec decodes a json spec, unknown fields are rejected
+func ReadSpec(r io.Reader) (Spec, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var spec Spec
+	err := dec.Decode(&spec)
+	return spec, err
+}
+
+// BuildFromSpec validates the spec and builds the network
+func BuildFromSpec(spec Spec) (Network, error) {
+	switch {
+	case spec.Inputs <= 0:
+		return Network{}, fmt.Errorf("number of inputs shall be positive")
+	case len(spec.Layers) == 0:
+		return Network{}, fmt.Errorf("at least one layer expected")
+	case spec.Optimizer.Type != "" && spec.Optimizer.Type != "sgd":
+		return Network{}, fmt.Errorf("unknown optimizer %q", spec.Optimizer.Type)
+	case spec.Optimizer.LearningRate <= 0:
+		return Network{}, fmt.Errorf("learning rate shall be positive")
+	case spec.Loss != "" && spec.Loss != "mse":
+		return Network{}, fmt.Errorf("unknown loss %q", spec.Loss)
+	}
+
+	net := NewNetwork(spec.Optimizer.LearningRate, spec.Inputs)
+	for i, layer := range spec.Layers {
+		bld, act, err := layer.build()
+		if err != nil {
+			return Network{}, fmt.Errorf("layer %d: %w", i, err)
+		}
+		net.AddLayer(bld, layer.Neurons, act)
+	}
+
+	criteria, err := spec.Stop.criteria()
+	if err != nil {
+		return Network{}, err
+	}
+	net.Stop.On(criteria...)
+	return net, nil
+}
+
+// build checks the layer spec and converts it to a builder and an activator
+func (ls LayerSpec) build() (LayerBuilder, Activator, error) {
+	if ls.Type != "" && ls.Type != "linear" {
+		return nil, nil, fmt.Errorf("unknown layer type %q", ls.Type)
+	}
+	if ls.Neurons <= 0 {
+		return nil, nil, fmt.Errorf("number of neurons shall be positive")
+	}
+	act, err := ParseActivator(ls.Activator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bld := LinearBuilder{}
+	if ls.Initializer != "" {
+		bld.Initializer, err = ParseInitializer(ls.Initializer)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if ls.Regularization != nil {
+		if ls.Regularization.L1 < 0 || ls.Regularization.L2 < 0 {
+			return nil, nil, fmt.Errorf("regularization shall be positive")
+		}
+		bld.Regularization = *ls.Regularization
+	}
+	return bld, act, nil
+}
+
+// criteria converts the stop spec
+func (ss StopSpec) criteria() ([]Criterion, error) {
+	var criteria []Criterion
+	if ss.Epoch != nil {
+		criteria = append(criteria, MaxEpoch(*ss.Epoch))
+	}
+	if ss.Duration != "" {
+		duration, err := time.ParseDuration(ss.Duration)
+		if err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, MaxDuration(duration))
+	}
+	if ss.Error != nil {
+		criteria = append(criteria, MinMeanSquaredError(*ss.Error))
+	}
+	if ss.ValidationAccuracy != nil {
+		criteria = append(criteria, MinValidationAccuracy(*ss.ValidationAccuracy))
+	}
+	if ss.GradientNorm != nil {
+		criteria = append(criteria, MinGradientNorm(*ss.GradientNorm))
+	}
+	if ss.NonFinite != nil {
+		criteria = append(criteria, MaxNonFinite(*ss.NonFinite))
+	}
+	if ss.Plateau != nil {
+		if ss.Plateau.Patience <= 0 {
+			return nil, fmt.Errorf("plateau patience shall be positive")
+		}
+		criteria = append(criteria, Plateau(ss.Plateau.Patience, ss.Plateau.Delta))
+	}
+	return criteria, nil
+}
+
+// Spec regenerates the description of the network
+// Only simple stop criteria (not combined, no predicate) can be described
+func (net Network) Spec() (Spec, error) {
+	spec := Spec{
+		Inputs: net.in(),
+		Optimizer: OptimizerSpec{
+			Type:         "sgd",
+			LearningRate: net.learningRate,
+		},
+		Loss: "mse",
+	}
+
+	// Layers are expected as pairs of linear + activator
+	if len(net.layers)%2 != 0 {
+		return Spec{}, fmt.Errorf("layers cannot be described")
+	}
+	for i := 0; i < len(net.layers); i += 2 {
+		linear, okLinear := net.layers[i].(Linear)
+		activ, okActiv := net.layers[i+1].(ActivatorLayer)
+		if !okLinear || !okActiv {
+			return Spec{}, fmt.Errorf("layer %d cannot be described", i)
+		}
+
+		layer := LayerSpec{
+			Type:        linear.Type(),
+			Neurons:     len(linear.biaises),
+			Activator:   activ.act.String(),
+			Initializer: linear.initializer,
+		}
+		if linear.regularization != (Regularization{}) {
+			reg := linear.regularization
+			layer.Regularization = &reg
+		}
+		spec.Layers = append(spec.Layers, layer)
+	}
+
+	// Stop criteria
+	for _, crit := range net.Stop.criteria {
+		switch c := crit.(type) {
+		case maxEpoch:
+			epoch := int(c)
+			spec.Stop.Epoch = &epoch
+		case maxDuration:
+			spec.Stop.Duration = time.Duration(c).String()
+		case minMeanSquaredError:
+			mse := float64(c)
+			spec.Stop.Error = &mse
+		case minValidationAccuracy:
+			acc := float64(c)
+			spec.Stop.ValidationAccuracy = &acc
+		case minGradientNorm:
+			norm := float64(c)
+			spec.Stop.GradientNorm = &norm
+		case maxNonFinite:
+			count := int(c)
+			spec.Stop.NonFinite = &count
+		case plateau:
+			spec.Stop.Plateau = &PlateauSpec{Patience: c.patience, Delta: c.minDelta}
+		default:
+			return Spec{}, fmt.Errorf("stop criterion %q cannot be described", crit)
+		}
+	}
+	return spec, nil
+}
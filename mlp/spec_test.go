@@ -0,0 +1,110 @@
+package mlp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSpec(t *testing.T) {
+	Convey("spec", t, func() {
+		content := `{
+			"inputs": 2,
+			"layers": [
+				{"type": "linear", "neurons": 3, "activator": "htan", "initializer": "xavier"},
+				{"neurons": 2, "activator": "relu", "initializer": "he", "regularization": {"l2": 0.01}},
+				{"neurons": 1, "activator": "sigmoid"}
+			],
+			"optimizer": {"type": "sgd", "learning-rate": 0.3},
+			"loss": "mse",
+			"stop": {"epoch": 100, "duration": "1s", "plateau": {"patience": 10, "delta": 0.001}}
+		}`
+
+		Convey("build and regenerate", func() {
+			spec, err := ReadSpec(strings.NewReader(content))
+			So(err, ShouldBeNil)
+
+			net, err := BuildFromSpec(spec)
+			So(err, ShouldBeNil)
+			So(net.neurons, ShouldResemble, []int{2, 3, 2, 1})
+			So(net.layers, ShouldHaveLength, 6)
+			So(net.layers[2].(Linear).regularization, ShouldResemble, Regularization{L2: 0.01})
+			So(net.Stop.criteria, ShouldResemble, []Criterion{
+				MaxEpoch(100), MaxDuration(time.Second), Plateau(10, 0.001),
+			})
+
+			regen, err := net.Spec()
+			So(err, ShouldBeNil)
+			spec.Layers[0].Type = "linear"
+			spec.Layers[1].Type = "linear"
+			spec.Layers[2].Type = "linear"
+			So(regen, ShouldResemble, spec)
+		})
+
+		Convey("regenerate after marshal", func() {
+			spec, err := ReadSpec(strings.NewReader(content))
+			So(err, ShouldBeNil)
+			net1, err := BuildFromSpec(spec)
+			So(err, ShouldBeNil)
+			js, err := net1.MarshalJSON()
+			So(err, ShouldBeNil)
+
+			net2 := Network{}
+			So(net2.UnmarshalJSON(js), ShouldBeNil)
+			spec1, err := net1.Spec()
+			So(err, ShouldBeNil)
+			spec2, err := net2.Spec()
+			So(err, ShouldBeNil)
+			So(spec2.Layers, ShouldResemble, spec1.Layers)
+		})
+
+		Convey("errors", func() {
+			valid := func() Spec {
+				spec, err := ReadSpec(strings.NewReader(content))
+				So(err, ShouldBeNil)
+				return spec
+			}
+
+			_, err := ReadSpec(strings.NewReader(`{"inputs": 2, "unknown": 1}`))
+			So(err, ShouldNotBeNil)
+
+			spec := valid()
+			spec.Inputs = 0
+			_, err = BuildFromSpec(spec)
+			So(err, ShouldBeError, "number of inputs shall be positive")
+
+			spec = valid()
+			spec.Optimizer.Type = "adam"
+			_, err = BuildFromSpec(spec)
+			So(err, ShouldBeError, `unknown optimizer "adam"`)
+
+			spec = valid()
+			spec.Loss = "cross-entropy"
+			_, err = BuildFromSpec(spec)
+			So(err, ShouldBeError, `unknown loss "cross-entropy"`)
+
+			spec = valid()
+			spec.Layers[1].Initializer = "zeros"
+			_, err = BuildFromSpec(spec)
+			So(err, ShouldBeError, `layer 1: unknown initializer "zeros"`)
+
+			spec = valid()
+			spec.Layers[2].Type = "conv"
+			_, err = BuildFromSpec(spec)
+			So(err, ShouldBeError, `layer 2: unknown layer type "conv"`)
+
+			spec = valid()
+			spec.Stop.Duration = "forever"
+			_, err = BuildFromSpec(spec)
+			So(err, ShouldNotBeNil)
+
+			net, err := BuildFromSpec(valid())
+			So(err, ShouldBeNil)
+			net.Stop.On(Predicate("custom", func(History) bool { return true }))
+			_, err = net.Spec()
+			So(err, ShouldBeError, `stop criterion "custom" cannot be described`)
+		})
+	})
+}
@@ -0,0 +1,135 @@
+package mlp
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// LayerSummary describes one layer of the network
+type LayerSummary struct {
+	Type       string `json:"type"`
+	Inputs     int    `json:"inputs"`              // Number of input values
+	Outputs    int    `json:"outputs"`             // Number of output values
+	Shape      string `json:"shape,omitempty"`     // Shape of the output image (image layers only)
+	Activator  string `json:"activator,omitempty"` // Activation function (activator layers only)
+	Parameters int    `json:"parameters"`          // Number of trainable parameters
+	Frozen     bool   `json:"frozen,omitempty"`    // Parameters are not updated during training
+}
+
+// Summary describes the layers of the network
+type Summary struct {
+	Inputs       int
+	Outputs      int
+	LearningRate float64
+	Layers       []LayerSummary
+	Parameters   int // Total number of trainable parameters
+	Trainable    int // Number of parameters updated during training (not frozen)
+	Memory       int // Estimated size in bytes of the parameters and their gradients
+}
+
+// summarizer is implemented by layers changing the shape of the data or holding parameters
+type summarizer interface {
+	summary(in int) LayerSummary
+}
+
+// outputSize computes the number of values produced by a sequence of layers receiving in values
+func outputSize(in int, layers []Layer) (int, error) {
+	for _, layer := range layers {
+		switch l := layer.(type) {
+		case Linear:
+			if rows, _ := l.Shape(); rows != in {
+				return 0, fmt.Errorf("linear layer inputs (%d) do not match %d values", rows, in)
+			}
+		case Embedding:
+			if l.in != in {
+				return 0, fmt.Errorf("embedding layer inputs (%d) do not match %d values", l.in, in)
+			}
+		case Residual:
+			if _, err := l.check(in); err != nil {
+				return 0, err
+			}
+		case imageLayer:
+			if shape := l.InputShape(); shape.Size() != in {
+				return 0, fmt.Errorf("%s layer shape %s does not match %d values", layer.Type(), shape, in)
+			}
+		}
+		if s, ok := layer.(summarizer); ok {
+			in = s.summary(in).Outputs
+		}
+	}
+	return in, nil
+}
+
+// Summary lists the layers of the network with their shape and number of parameters
+func (net Network) Summary() Summary {
+	sum := Summary{
+		Inputs:       net.in(),
+		Outputs:      net.out(),
+		LearningRate: net.learningRate,
+		Layers:       make([]LayerSummary, len(net.layers)),
+	}
+
+	in := net.in()
+	for i, layer := range net.layers {
+		ls := LayerSummary{
+			Type:    layer.Type(),
+			Inputs:  in,
+			Outputs: in,
+		}
+		if s, ok := layer.(summarizer); ok {
+			ls = s.summary(in)
+		}
+		if al, ok := layer.(ActivatorLayer); ok {
+			ls.Activator = al.act.String()
+		}
+		ls.Frozen = net.frozen[i]
+		sum.Layers[i] = ls
+		sum.Parameters += ls.Parameters
+		if !ls.Frozen {
+			sum.Trainable += ls.Parameters
+		}
+		in = ls.Outputs
+	}
+	sum.Memory = 2 * 8 * sum.Parameters // float64 parameters and gradients
+	return sum
+}
+
+func (sum Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "inputs: %d\noutputs: %d\nlearning rate: %g\n\n", sum.Inputs, sum.Outputs, sum.LearningRate)
+	writer := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "#\ttype\tinput\toutput\tactivator\tparameters")
+	for i, ls := range sum.Layers {
+		typ := ls.Type
+		if ls.Frozen {
+			typ += " (frozen)"
+		}
+		output := fmt.Sprint(ls.Outputs)
+		if ls.Shape != "" {
+			output += " (" + ls.Shape + ")"
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%s\t%s\t%d\n", i, typ, ls.Inputs, output, ls.Activator, ls.Parameters)
+	}
+	writer.Flush()
+	fmt.Fprintf(&b, "\ntotal parameters: %d\n", sum.Parameters)
+	if sum.Trainable != sum.Parameters {
+		fmt.Fprintf(&b, "trainable parameters: %d\n", sum.Trainable)
+	}
+	fmt.Fprintf(&b, "memory: %s\n", bytesSize(sum.Memory))
+	return b.String()
+}
+
+// bytesSize formats a number of bytes
+func bytesSize(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	size, prefix := float64(n)/unit, 0
+	for size >= unit && prefix < 3 {
+		size /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", size, "KMGT"[prefix])
+}
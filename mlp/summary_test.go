@@ -0,0 +1,63 @@
+package mlp
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSummary(t *testing.T) {
+	Convey("summary", t, func() {
+		net := NewNetwork(0.3, 2)
+		net.AddLayer(LinearBuilder{}, 3, Sigmoid{})
+		net.AddLayer(LinearBuilder{}, 1, Htan{})
+
+		Convey("layers and parameters", func() {
+			sum := net.Summary()
+			So(sum.Inputs, ShouldEqual, 2)
+			So(sum.Outputs, ShouldEqual, 1)
+			So(sum.LearningRate, ShouldEqual, 0.3)
+			So(sum.Layers, ShouldResemble, []LayerSummary{
+				{Type: "linear", Inputs: 2, Outputs: 3, Parameters: 9},
+				{Type: "activator", Inputs: 3, Outputs: 3, Activator: "sigmoid"},
+				{Type: "linear", Inputs: 3, Outputs: 1, Parameters: 4},
+				{Type: "activator", Inputs: 1, Outputs: 1, Activator: "htan"},
+			})
+			So(sum.Parameters, ShouldEqual, 13)
+			So(sum.Memory, ShouldEqual, 208)
+
+			str := sum.String()
+			So(str, ShouldContainSubstring, "total parameters: 13\nmemory: 208 B\n")
+			So(str, ShouldContainSubstring, "linear")
+			So(bytesSize(3*1024*1024/2), ShouldEqual, "1.5 MiB")
+		})
+
+		Convey("read-only accessors", func() {
+			So(net.Neurons(), ShouldResemble, []int{2, 3, 1})
+			So(net.LearningRate(), ShouldEqual, 0.3)
+
+			layers := net.Layers()
+			So(layers, ShouldHaveLength, 4)
+			ln := layers[0].(Linear)
+			in, out := ln.Shape()
+			So(in, ShouldEqual, 2)
+			So(out, ShouldEqual, 3)
+			So(layers[1].(ActivatorLayer).Activator(), ShouldResemble, Sigmoid{})
+
+			// Copies do not modify the network
+			weights := ln.Weights()
+			So(weights, ShouldHaveLength, 2)
+			weights[0][0] = 42
+			ln.Biases()[0] = 42
+			So(net.layers[0].(Linear).weights[0][0], ShouldNotEqual, 42)
+			So(net.layers[0].(Linear).biaises[0], ShouldEqual, 0)
+
+			// Build from weights
+			ln2, err := NewLinearWeights(ln.Weights(), ln.Biases())
+			So(err, ShouldBeNil)
+			So(ln2, ShouldResemble, ln)
+			_, err = NewLinearWeights([][]float64{{1, 2}}, []float64{0})
+			So(err, ShouldBeError, "weights row 0 (2) does not match biases (1)")
+		})
+	})
+}
@@ -1,59 +1,168 @@
 package mlp
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
+	"math"
 	"time"
 )
 
+// Metrics computed at the end of an epoch
+type Metrics struct {
+	Epoch              int           // Epoch index (starting at 0)
+	Duration           time.Duration // Time elapsed since the training started
+	EpochDuration      time.Duration // Time spent on the epoch (validation included)
+	MeanSquaredError   float64       // Mean of the finite sample errors of the epoch (+Inf if none)
+	GradientNorm       float64       // Mean of the sample gradient norms of the epoch
+	NonFinite          int           // Total number of non finite (NaN or infinite) sample errors
+	ValidationError    float64       // Mean squared error on the validation data (NaN if not set)
+	ValidationAccuracy float64       // Accuracy on the validation data (NaN if not set)
+	Samples            int           // Number of samples trained during the epoch
+	LearningRate       float64       // Learning rate used during the epoch
+}
+
+// for marshal/unmarshal metrics (NaN values are exported as null)
+type exportMetrics struct {
+	Epoch              int           `json:"epoch"`
+	Duration           time.Duration `json:"duration"`
+	EpochDuration      time.Duration `json:"epoch-duration,omitempty"`
+	MeanSquaredError   *float64      `json:"error"`
+	GradientNorm       *float64      `json:"gradient-norm"`
+	NonFinite          int           `json:"non-finite"`
+	ValidationError    *float64      `json:"validation-error"`
+	ValidationAccuracy *float64      `json:"validation-accuracy"`
+	Samples            int           `json:"samples,omitempty"`
+	LearningRate       float64       `json:"learning-rate,omitempty"`
+}
+
+// finite returns nil if the value cannot be exported
+func finite(x float64) *float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil
+	}
+	return &x
+}
+
+// orNaN returns NaN if the value is not set
+func orNaN(x *float64) float64 {
+	if x == nil {
+		return math.NaN()
+	}
+	return *x
+}
+
+func (m Metrics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(exportMetrics{
+		Epoch:              m.Epoch,
+		Duration:           m.Duration,
+		EpochDuration:      m.EpochDuration,
+		MeanSquaredError:   finite(m.MeanSquaredError),
+		GradientNorm:       finite(m.GradientNorm),
+		NonFinite:          m.NonFinite,
+		ValidationError:    finite(m.ValidationError),
+		ValidationAccuracy: finite(m.ValidationAccuracy),
+		Samples:            m.Samples,
+		LearningRate:       m.LearningRate,
+	})
+}
+
+func (m *Metrics) UnmarshalJSON(data []byte) error {
+	var exp exportMetrics
+	err := json.Unmarshal(data, &exp)
+	if err != nil {
+		return err
+	}
+
+	*m = Metrics{
+		Epoch:              exp.Epoch,
+		Duration:           exp.Duration,
+		EpochDuration:      exp.EpochDuration,
+		MeanSquaredError:   orNaN(exp.MeanSquaredError),
+		GradientNorm:       orNaN(exp.GradientNorm),
+		NonFinite:          exp.NonFinite,
+		ValidationError:    orNaN(exp.ValidationError),
+		ValidationAccuracy: orNaN(exp.ValidationAccuracy),
+		Samples:            exp.Samples,
+		LearningRate:       exp.LearningRate,
+	}
+	return nil
+}
+
+// Observer is notified with the metrics at the end of each epoch (see Network.Observe)
+type Observer interface {
+	Observe(m Metrics)
+}
+
+// ObserverFunc is a function observer
+type ObserverFunc func(m Metrics)
+
+// Observe calls the function
+func (fn ObserverFunc) Observe(m Metrics) {
+	fn(m)
+}
+
+// History of the metrics, one item per epoch
+type History []Metrics
+
+// Last returns the metrics of the last epoch
+func (h History) Last() Metrics {
+	return h[len(h)-1]
+}
+
+// Termination holds the stop criteria of the training,
+// and once the training is over, the criterion reached and the history
 type Termination struct {
-	epoch            *int
-	duration         *time.Duration
-	meanSquaredError *float64
+	criteria []Criterion // Stop criteria, the first reached stops the training
+	reached  Criterion   // Criterion that stopped the training
+	history  History     // Training history
 }
 
+// On adds stop criteria
+func (tn *Termination) On(criteria ...Criterion) {
+	tn.criteria = append(tn.criteria, criteria...)
+}
+
+// OnEpoch stops on the given epoch
 func (tn *Termination) OnEpoch(epoch int) {
-	tn.epoch = &epoch
+	tn.On(MaxEpoch(epoch))
 }
 
+// OnDuration stops after the given duration
 func (tn *Termination) OnDuration(duration time.Duration) {
-	tn.duration = &duration
+	tn.On(MaxDuration(duration))
 }
 
+// OnMeanSquaredError stops when the error is low enough
 func (tn *Termination) OnMeanSquaredError(mse float64) {
-	tn.meanSquaredError = &mse
-}
-
-func (tn Termination) hasReached(current Termination) bool {
-	empty := tn.epoch == nil && tn.duration == nil && tn.meanSquaredError == nil
-
-	switch {
-	case empty:
-		return true // stop if all empty
-	case tn.epoch != nil && current.epoch != nil &&
-		*current.epoch >= *tn.epoch:
-		return true // stop if epoch reached
-	case tn.duration != nil && current.duration != nil &&
-		*current.duration >= *tn.duration:
-		return true // stop if duration reached
-	case tn.meanSquaredError != nil && current.meanSquaredError != nil &&
-		*current.meanSquaredError <= *tn.meanSquaredError:
-		return true // stop if mse reached
-	default:
-		return false
+	tn.On(MinMeanSquaredError(mse))
+}
+
+// hasReached returns the first criterion reached, or nil if the training shall continue
+func (tn Termination) hasReached(history History) Criterion {
+	if len(tn.criteria) == 0 {
+		return MaxEpoch(0) // stop if all empty
 	}
+	return or(tn.criteria).fired(history)
+}
+
+// Reached returns the criterion that stopped the training
+func (tn Termination) Reached() Criterion {
+	return tn.reached
+}
+
+// History returns the metrics of all processed epochs
+func (tn Termination) History() History {
+	return tn.history
 }
 
 func (tn Termination) String() string {
-	var str []string
-	if tn.epoch != nil {
-		str = append(str, fmt.Sprintf("epoch: %d", *tn.epoch))
-	}
-	if tn.duration != nil {
-		str = append(str, fmt.Sprintf("duration: %s", *tn.duration))
+	if len(tn.history) == 0 {
+		return joinCriteria(tn.criteria, ", ")
 	}
-	if tn.meanSquaredError != nil {
-		str = append(str, fmt.Sprintf("error: %f", *tn.meanSquaredError))
+	last := tn.history.Last()
+	str := fmt.Sprintf("epoch: %d, duration: %s, error: %f", last.Epoch, last.Duration, last.MeanSquaredError)
+	if tn.reached != nil {
+		str += fmt.Sprintf(", reached: %s", tn.reached)
 	}
-	return strings.Join(str, ", ")
+	return str
 }
@@ -0,0 +1,136 @@
+package mlp
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCriterion(t *testing.T) {
+	Convey("criterion", t, func() {
+		history := History{
+			{Epoch: 0, Duration: time.Second, MeanSquaredError: 0.5, GradientNorm: 1},
+			{Epoch: 1, Duration: 2 * time.Second, MeanSquaredError: 0.3, GradientNorm: 0.1},
+			{Epoch: 2, Duration: 3 * time.Second, MeanSquaredError: 0.31, GradientNorm: 0.01, NonFinite: 2},
+			{Epoch: 3, Duration: 4 * time.Second, MeanSquaredError: 0.32, GradientNorm: 0.001, NonFinite: 3,
+				ValidationAccuracy: 0.9},
+		}
+
+		Convey("simple", func() {
+			So(MaxEpoch(3).Reached(history), ShouldBeTrue)
+			So(MaxEpoch(4).Reached(history), ShouldBeFalse)
+			So(MaxDuration(4*time.Second).Reached(history), ShouldBeTrue)
+			So(MaxDuration(5*time.Second).Reached(history), ShouldBeFalse)
+			So(MinMeanSquaredError(0.32).Reached(history), ShouldBeTrue)
+			So(MinMeanSquaredError(0.3).Reached(history), ShouldBeFalse)
+			So(MinValidationAccuracy(0.9).Reached(history), ShouldBeTrue)
+			So(MinValidationAccuracy(0.95).Reached(history), ShouldBeFalse)
+			So(MinGradientNorm(0.001).Reached(history), ShouldBeTrue)
+			So(MinGradientNorm(0.0001).Reached(history), ShouldBeFalse)
+			So(MaxNonFinite(2).Reached(history), ShouldBeTrue)
+			So(MaxNonFinite(3).Reached(history), ShouldBeFalse)
+			So(MaxEpoch(0).Reached(nil), ShouldBeFalse)
+		})
+
+		Convey("not set validation", func() {
+			h := History{{ValidationAccuracy: math.NaN()}}
+			So(MinValidationAccuracy(0).Reached(h), ShouldBeFalse)
+		})
+
+		Convey("plateau", func() {
+			So(Plateau(2, 0).Reached(history), ShouldBeTrue)
+			So(Plateau(3, 0).Reached(history), ShouldBeFalse)
+			So(Plateau(4, 0).Reached(history), ShouldBeFalse)
+			So(Plateau(1, 0.1).Reached(history[:2]), ShouldBeFalse)
+			So(Plateau(1, 0.3).Reached(history[:2]), ShouldBeTrue)
+		})
+
+		Convey("predicate", func() {
+			crit := Predicate("long history", func(h History) bool { return len(h) > 3 })
+			So(crit.Reached(history), ShouldBeTrue)
+			So(crit.Reached(history[:3]), ShouldBeFalse)
+			So(crit.String(), ShouldEqual, "long history")
+		})
+
+		Convey("combinators", func() {
+			So(And(MaxEpoch(3), MinMeanSquaredError(0.4)).Reached(history), ShouldBeTrue)
+			So(And(MaxEpoch(3), MinMeanSquaredError(0.1)).Reached(history), ShouldBeFalse)
+			So(And().Reached(history), ShouldBeFalse)
+			So(Or(MaxEpoch(10), MinMeanSquaredError(0.4)).Reached(history), ShouldBeTrue)
+			So(Or(MaxEpoch(10), MinMeanSquaredError(0.1)).Reached(history), ShouldBeFalse)
+			So(Not(MaxEpoch(10)).Reached(history), ShouldBeTrue)
+			So(
+				And(MaxEpoch(1), Not(MinGradientNorm(0.1))).String(),
+				ShouldEqual,
+				"(epoch >= 1 and not gradient norm <= 0.1)",
+			)
+		})
+
+		Convey("termination", func() {
+			Convey("empty", func() {
+				tn := Termination{}
+				So(tn.hasReached(history[:1]), ShouldResemble, MaxEpoch(0))
+			})
+
+			Convey("first reached", func() {
+				tn := Termination{}
+				tn.OnEpoch(10)
+				tn.On(Or(MaxDuration(time.Minute), MinGradientNorm(0.01)), MaxNonFinite(0))
+				So(tn.hasReached(history[:2]), ShouldBeNil)
+				So(tn.hasReached(history[:3]), ShouldResemble, MinGradientNorm(0.01))
+			})
+		})
+	})
+}
+
+func TestTrain(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("train", t, func() {
+		rand.Seed(42)
+		net := NewNetwork(0.3, 2)
+		net.AddLayer(LinearBuilder{}, 3, Sigmoid{})
+		net.AddLayer(LinearBuilder{}, 1, Sigmoid{})
+
+		xData := [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+		yData := [][]float64{{0}, {1}, {1}, {0}}
+
+		Convey("history", func() {
+			net.Stop.OnEpoch(9)
+			term, err := net.Train(ctx, xData, yData)
+			So(err, ShouldBeNil)
+			So(term.Reached(), ShouldResemble, MaxEpoch(9))
+			So(term.History(), ShouldHaveLength, 10)
+			So(term.History().Last().Epoch, ShouldEqual, 9)
+			So(math.IsNaN(term.History().Last().ValidationAccuracy), ShouldBeTrue)
+		})
+
+		Convey("validation", func() {
+			net.SetValidation(xData, yData)
+			net.Stop.On(MinValidationAccuracy(1))
+			net.Stop.OnEpoch(20000)
+			term, err := net.Train(ctx, xData, yData)
+			So(err, ShouldBeNil)
+			So(term.Reached(), ShouldResemble, MinValidationAccuracy(1))
+
+			eval, err := net.Evaluate(xData, yData)
+			So(err, ShouldBeNil)
+			So(eval.Accuracy, ShouldEqual, 1)
+			So(eval.MeanSquaredError, ShouldEqual, term.History().Last().ValidationError)
+		})
+
+		Convey("predicate on history", func() {
+			net.Stop.On(Predicate("error increases", func(h History) bool {
+				return len(h) > 1 && h.Last().MeanSquaredError > h[len(h)-2].MeanSquaredError
+			}))
+			net.Stop.OnEpoch(20000)
+			term, err := net.Train(ctx, xData, yData)
+			So(err, ShouldBeNil)
+			So(term.Reached(), ShouldNotBeNil)
+		})
+	})
+}
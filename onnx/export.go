@@ -0,0 +1,239 @@
+// Package onnx exports networks to the onnx format (and imports them back):
+// linear layers are written as Gemm nodes whose weights are initializers, activators as
+// Sigmoid, Tanh or Relu nodes, with an optional final Softmax
+package onnx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Versions of the format and of the operators
+const (
+	irVersion    = 7
+	opsetVersion = 13
+)
+
+// Tensor element types
+const (
+	typeFloat  = 1
+	typeDouble = 11
+)
+
+// Attribute types
+const (
+	attributeFloat = 1
+	attributeInt   = 2
+)
+
+// Field numbers of the onnx messages
+const (
+	modelIRVersion     = 1
+	modelProducerName  = 2
+	modelGraph         = 7
+	modelOpsetImport   = 8
+	modelMetadataProps = 14
+
+	opsetVersionField = 2
+
+	entryKey   = 1
+	entryValue = 2
+
+	graphNode        = 1
+	graphName        = 2
+	graphInitializer = 5
+	graphInput       = 11
+	graphOutput      = 12
+
+	nodeInput     = 1
+	nodeOutput    = 2
+	nodeName      = 3
+	nodeOpType    = 4
+	nodeAttribute = 5
+
+	attributeName  = 1
+	attributeF     = 2
+	attributeI     = 3
+	attributeType  = 20
+	tensorDims     = 1
+	tensorDataType = 2
+	tensorFloats   = 4
+	tensorName     = 8
+	tensorRawData  = 9
+	tensorDoubles  = 10
+
+	valueName      = 1
+	valueType      = 2
+	typeTensor     = 1
+	tensorElemType = 1
+	tensorShape    = 2
+	shapeDim       = 1
+	dimValue       = 1
+	dimParam       = 2
+)
+
+// activators converts the mlp activators to onnx operators
+var activators = map[string]string{
+	"sigmoid": "Sigmoid",
+	"htan":    "Tanh",
+	"relu":    "Relu",
+}
+
+// Options of the export
+type Options struct {
+	Float32 bool // Store weights and compute with 32 bits floats (default: 64 bits)
+	Softmax bool // Add a final Softmax node (outputs are probabilities), the model cannot be imported back
+}
+
+// node of the onnx graph
+type node struct {
+	op      string
+	inputs  []string
+	output  string
+	softmax bool // axis attribute
+}
+
+// tensor initializer of the onnx graph
+type tensor struct {
+	name   string
+	dims   []int64
+	values []float64
+}
+
+// Export writes the network as an onnx model (protobuf bytes), whose input "input"
+// and output "output" are [batch, features] tensors
+func Export(w io.Writer, net mlp.Network, opts Options) error {
+	if scaler := net.Scaler(); scaler != nil {
+		return fmt.Errorf("scaler %q is not supported, scale the inputs before the prediction", scaler.Type())
+	}
+	layers := net.Layers()
+	if len(layers) == 0 {
+		return fmt.Errorf("at least one layer expected")
+	}
+
+	// Nodes and weights
+	var nodes []node
+	var tensors []tensor
+	current := "input"
+	for k, layer := range layers {
+		output := fmt.Sprintf("layer%d", k)
+		switch l := layer.(type) {
+		case mlp.Linear:
+			in, out := l.Shape()
+			weights := make([]float64, 0, in*out)
+			for _, row := range l.Weights() {
+				weights = append(weights, row...)
+			}
+			tensors = append(tensors,
+				tensor{name: output + ".weights", dims: []int64{int64(in), int64(out)}, values: weights},
+				tensor{name: output + ".biases", dims: []int64{int64(out)}, values: l.Biases()},
+			)
+			nodes = append(nodes, node{op: "Gemm", inputs: []string{current, output + ".weights", output + ".biases"}, output: output})
+		case mlp.ActivatorLayer:
+			op, ok := activators[l.Activator().String()]
+			if !ok {
+				return fmt.Errorf("layer %d: activator %q is not supported", k, l.Activator())
+			}
+			nodes = append(nodes, node{op: op, inputs: []string{current}, output: output})
+		default:
+			return fmt.Errorf("layer %d: type %q is not supported", k, layer.Type())
+		}
+		current = output
+	}
+	if opts.Softmax {
+		nodes = append(nodes, node{op: "Softmax", inputs: []string{current}, output: "softmax", softmax: true})
+		current = "softmax"
+	}
+	nodes = append(nodes, node{op: "Identity", inputs: []string{current}, output: "output"})
+
+	elemType := int64(typeDouble)
+	if opts.Float32 {
+		elemType = typeFloat
+	}
+	neurons := net.Neurons()
+
+	// Model
+	var model encoder
+	model.int(modelIRVersion, irVersion)
+	model.string(modelProducerName, "simlpe")
+	model.message(modelOpsetImport, func(m *encoder) {
+		m.int(opsetVersionField, opsetVersion)
+	})
+	model.message(modelMetadataProps, func(m *encoder) {
+		m.string(entryKey, "learning-rate")
+		m.string(entryValue, strconv.FormatFloat(net.LearningRate(), 'g', -1, 64))
+	})
+	model.message(modelGraph, func(g *encoder) {
+		g.string(graphName, "simlpe")
+		for i, n := range nodes {
+			g.message(graphNode, func(m *encoder) {
+				for _, input := range n.inputs {
+					m.string(nodeInput, input)
+				}
+				m.string(nodeOutput, n.output)
+				m.string(nodeName, fmt.Sprintf("%s%d", n.op, i))
+				m.string(nodeOpType, n.op)
+				if n.softmax {
+					m.message(nodeAttribute, func(a *encoder) {
+						a.string(attributeName, "axis")
+						a.int(attributeI, 1)
+						a.int(attributeType, attributeInt)
+					})
+				}
+			})
+		}
+		for _, t := range tensors {
+			g.message(graphInitializer, func(m *encoder) {
+				m.packedInts(tensorDims, t.dims)
+				m.int(tensorDataType, elemType)
+				m.string(tensorName, t.name)
+				m.bytes(tensorRawData, rawData(t.values, opts.Float32))
+			})
+		}
+		g.message(graphInput, func(m *encoder) {
+			writeValueInfo(m, "input", elemType, neurons[0])
+		})
+		g.message(graphOutput, func(m *encoder) {
+			writeValueInfo(m, "output", elemType, neurons[len(neurons)-1])
+		})
+	})
+
+	_, err := w.Write(model.buf)
+	return err
+}
+
+// writeValueInfo writes the name and the [batch, size] shape of a graph input or output
+func writeValueInfo(m *encoder, name string, elemType int64, size int) {
+	m.string(valueName, name)
+	m.message(valueType, func(t *encoder) {
+		t.message(typeTensor, func(tt *encoder) {
+			tt.int(tensorElemType, elemType)
+			tt.message(tensorShape, func(s *encoder) {
+				s.message(shapeDim, func(d *encoder) {
+					d.string(dimParam, "batch")
+				})
+				s.message(shapeDim, func(d *encoder) {
+					d.int(dimValue, int64(size))
+				})
+			})
+		})
+	})
+}
+
+// rawData converts values to little endian floats or doubles
+func rawData(values []float64, single bool) []byte {
+	var b []byte
+	for _, v := range values {
+		if single {
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v)))
+		} else {
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+		}
+	}
+	return b
+}
@@ -0,0 +1,424 @@
+package onnx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// importedNode is a node read from an onnx graph
+type importedNode struct {
+	op     string
+	inputs []string
+	output string
+	ints   map[string]int64
+	floats map[string]float64
+}
+
+// importedGraph is the content of an onnx graph
+type importedGraph struct {
+	nodes   []importedNode
+	tensors map[string]tensor
+	inputs  []tensor // Name and shape of the graph inputs (initializers excluded)
+	outputs []string
+}
+
+// linearBuilder returns an already built linear layer
+type linearBuilder struct {
+	ln mlp.Linear
+}
+
+func (bld linearBuilder) New(in, out int) mlp.Layer {
+	return bld.ln
+}
+
+// Import reads an onnx model made of Gemm (or MatMul + Add) nodes, each one followed by
+// a Sigmoid, Tanh or Relu node (Identity nodes are skipped).
+// Softmax nodes are not supported: models exported with Options.Softmax cannot be imported back.
+func Import(r io.Reader) (mlp.Network, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return mlp.Network{}, err
+	}
+	fields, err := decode(content)
+	if err != nil {
+		return mlp.Network{}, err
+	}
+
+	learningRate := 0.1
+	var g *importedGraph
+	for _, f := range fields {
+		switch f.num {
+		case modelGraph:
+			g, err = readGraph(f.data)
+			if err != nil {
+				return mlp.Network{}, err
+			}
+		case modelMetadataProps:
+			key, value, err := readEntry(f.data)
+			if err != nil {
+				return mlp.Network{}, err
+			}
+			if key == "learning-rate" {
+				if learningRate, err = strconv.ParseFloat(value, 64); err != nil {
+					return mlp.Network{}, err
+				}
+			}
+		}
+	}
+	if g == nil {
+		return mlp.Network{}, fmt.Errorf("graph expected")
+	}
+	return g.network(learningRate)
+}
+
+// network converts the graph nodes to layers
+func (g importedGraph) network(learningRate float64) (mlp.Network, error) {
+	if len(g.inputs) != 1 || len(g.outputs) != 1 {
+		return mlp.Network{}, fmt.Errorf("one input and one output expected")
+	}
+	input := g.inputs[0]
+	if len(input.dims) != 2 || input.dims[1] <= 0 {
+		return mlp.Network{}, fmt.Errorf("input %q: [batch, features] shape expected", input.name)
+	}
+
+	net := mlp.NewNetwork(learningRate, int(input.dims[1]))
+	current := input.name
+	var linear *mlp.Linear // linear layer waiting for its activator
+	for i := 0; i < len(g.nodes); i++ {
+		n := g.nodes[i]
+		if len(n.inputs) == 0 || n.inputs[0] != current {
+			return mlp.Network{}, fmt.Errorf("node %d (%s): %q input expected", i, n.op, current)
+		}
+
+		switch n.op {
+		case "Gemm", "MatMul":
+			if linear != nil {
+				return mlp.Network{}, fmt.Errorf("node %d (%s): activator expected", i, n.op)
+			}
+			ln, next, err := g.linear(i)
+			if err != nil {
+				return mlp.Network{}, fmt.Errorf("node %d (%s): %w", i, n.op, err)
+			}
+			if in, _ := ln.Shape(); in != net.Neurons()[len(net.Neurons())-1] {
+				return mlp.Network{}, fmt.Errorf("node %d (%s): %d inputs found", i, n.op, in)
+			}
+			linear = &ln
+			i = next
+			n = g.nodes[i]
+		case "Sigmoid", "Tanh", "Relu":
+			if linear == nil {
+				return mlp.Network{}, fmt.Errorf("node %d (%s): linear layer expected", i, n.op)
+			}
+			var act mlp.Activator
+			for name, op := range activators {
+				if op == n.op {
+					act, _ = mlp.ParseActivator(name)
+				}
+			}
+			_, out := linear.Shape()
+			net.AddLayer(linearBuilder{ln: *linear}, out, act)
+			linear = nil
+		case "Identity":
+		default:
+			return mlp.Network{}, fmt.Errorf("node %d: operator %q is not supported", i, n.op)
+		}
+		current = n.output
+	}
+
+	switch {
+	case linear != nil:
+		return mlp.Network{}, fmt.Errorf("activator expected after the last linear layer")
+	case len(net.Layers()) == 0:
+		return mlp.Network{}, fmt.Errorf("at least one layer expected")
+	case current != g.outputs[0]:
+		return mlp.Network{}, fmt.Errorf("output %q does not match the last node", g.outputs[0])
+	}
+	return net, nil
+}
+
+// linear reads a Gemm node, or a MatMul node followed by an Add node, and returns the index of its last node
+func (g importedGraph) linear(i int) (mlp.Linear, int, error) {
+	n := g.nodes[i]
+	var biasName string
+	switch {
+	case n.op == "Gemm" && len(n.inputs) == 3:
+		biasName = n.inputs[2]
+		if alpha, ok := n.floats["alpha"]; ok && alpha != 1 {
+			return mlp.Linear{}, 0, fmt.Errorf("alpha shall be 1")
+		}
+		if beta, ok := n.floats["beta"]; ok && beta != 1 {
+			return mlp.Linear{}, 0, fmt.Errorf("beta shall be 1")
+		}
+		if n.ints["transA"] != 0 {
+			return mlp.Linear{}, 0, fmt.Errorf("transA is not supported")
+		}
+	case n.op == "MatMul" && len(n.inputs) == 2 && i+1 < len(g.nodes) && g.nodes[i+1].op == "Add":
+		add := g.nodes[i+1]
+		if len(add.inputs) != 2 || (add.inputs[0] != n.output && add.inputs[1] != n.output) {
+			return mlp.Linear{}, 0, fmt.Errorf("add node shall use the product")
+		}
+		biasName = add.inputs[0]
+		if biasName == n.output {
+			biasName = add.inputs[1]
+		}
+		i++
+	default:
+		return mlp.Linear{}, 0, fmt.Errorf("weights and biases expected")
+	}
+
+	w, okW := g.tensors[n.inputs[1]]
+	b, okB := g.tensors[biasName]
+	if !okW || !okB {
+		return mlp.Linear{}, 0, fmt.Errorf("weights and biases shall be initializers")
+	}
+	if len(w.dims) != 2 {
+		return mlp.Linear{}, 0, fmt.Errorf("2D weights expected")
+	}
+	in, out := int(w.dims[0]), int(w.dims[1])
+	transB := n.ints["transB"] != 0
+	if transB {
+		in, out = out, in
+	}
+	if in <= 0 || out <= 0 {
+		return mlp.Linear{}, 0, fmt.Errorf("weights shall not be empty")
+	}
+	if len(b.values) != out {
+		return mlp.Linear{}, 0, fmt.Errorf("%d biases found, %d expected", len(b.values), out)
+	}
+
+	weights := make([][]float64, in)
+	for r := range weights {
+		weights[r] = make([]float64, out)
+		for c := range weights[r] {
+			if transB {
+				weights[r][c] = w.values[c*in+r]
+			} else {
+				weights[r][c] = w.values[r*out+c]
+			}
+		}
+	}
+	ln, err := mlp.NewLinearWeights(weights, b.values)
+	return ln, i, err
+}
+
+// readGraph reads the nodes, the initializers, the inputs and the outputs
+func readGraph(b []byte) (*importedGraph, error) {
+	fields, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	g := &importedGraph{tensors: map[string]tensor{}}
+	var inputs []tensor
+	for _, f := range fields {
+		switch f.num {
+		case graphNode:
+			n, err := readNode(f.data)
+			if err != nil {
+				return nil, err
+			}
+			g.nodes = append(g.nodes, n)
+		case graphInitializer:
+			t, err := readTensor(f.data)
+			if err != nil {
+				return nil, err
+			}
+			g.tensors[t.name] = t
+		case graphInput:
+			input, err := readValueInfo(f.data)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, input)
+		case graphOutput:
+			output, err := readValueInfo(f.data)
+			if err != nil {
+				return nil, err
+			}
+			g.outputs = append(g.outputs, output.name)
+		}
+	}
+	for _, input := range inputs {
+		if _, ok := g.tensors[input.name]; !ok {
+			g.inputs = append(g.inputs, input)
+		}
+	}
+	return g, nil
+}
+
+// readNode reads the operator, the inputs, the output and the attributes of a node
+func readNode(b []byte) (importedNode, error) {
+	fields, err := decode(b)
+	if err != nil {
+		return importedNode{}, err
+	}
+	n := importedNode{ints: map[string]int64{}, floats: map[string]float64{}}
+	var outputs int
+	for _, f := range fields {
+		switch f.num {
+		case nodeInput:
+			n.inputs = append(n.inputs, string(f.data))
+		case nodeOutput:
+			n.output = string(f.data)
+			outputs++
+		case nodeOpType:
+			n.op = string(f.data)
+		case nodeAttribute:
+			attrs, err := decode(f.data)
+			if err != nil {
+				return importedNode{}, err
+			}
+			var name string
+			for _, a := range attrs {
+				if a.num == attributeName {
+					name = string(a.data)
+				}
+			}
+			for _, a := range attrs {
+				switch a.num {
+				case attributeF:
+					values, err := a.floats(false)
+					if err != nil {
+						return importedNode{}, err
+					}
+					if len(values) == 0 {
+						return importedNode{}, fmt.Errorf("attribute %q: value expected", name)
+					}
+					n.floats[name] = values[0]
+				case attributeI:
+					n.ints[name] = int64(a.value)
+				}
+			}
+		}
+	}
+	if outputs != 1 {
+		return importedNode{}, fmt.Errorf("node %s: one output expected", n.op)
+	}
+	return n, nil
+}
+
+// readTensor reads the name, the dimensions and the values of an initializer
+func readTensor(b []byte) (tensor, error) {
+	fields, err := decode(b)
+	if err != nil {
+		return tensor{}, err
+	}
+	var t tensor
+	var dataType uint64
+	var raw []byte
+	for _, f := range fields {
+		switch f.num {
+		case tensorDims:
+			dims, err := f.ints()
+			if err != nil {
+				return tensor{}, err
+			}
+			t.dims = append(t.dims, dims...)
+		case tensorDataType:
+			dataType = f.value
+		case tensorName:
+			t.name = string(f.data)
+		case tensorRawData:
+			raw = f.data
+		case tensorFloats, tensorDoubles:
+			values, err := f.floats(f.num == tensorDoubles)
+			if err != nil {
+				return tensor{}, err
+			}
+			t.values = append(t.values, values...)
+		}
+	}
+	if dataType != typeFloat && dataType != typeDouble {
+		return tensor{}, fmt.Errorf("tensor %q: data type %d is not supported", t.name, dataType)
+	}
+	if raw != nil {
+		if t.values, err = decodeFloats(raw, dataType == typeDouble); err != nil {
+			return tensor{}, fmt.Errorf("tensor %q: %w", t.name, err)
+		}
+	}
+
+	size := int64(1)
+	for _, dim := range t.dims {
+		if dim < 0 {
+			return tensor{}, fmt.Errorf("tensor %q: invalid dimension %d", t.name, dim)
+		}
+		if dim > 0 && size > math.MaxInt64/dim {
+			return tensor{}, fmt.Errorf("tensor %q: too many values", t.name)
+		}
+		size *= dim
+	}
+	if int64(len(t.values)) != size {
+		return tensor{}, fmt.Errorf("tensor %q: %d values found, %d expected", t.name, len(t.values), size)
+	}
+	return t, nil
+}
+
+// readValueInfo reads the name and the shape of a graph input or output (unknown dimensions are 0)
+func readValueInfo(b []byte) (tensor, error) {
+	var t tensor
+	err := walk(b, func(f field) error {
+		switch f.num {
+		case valueName:
+			t.name = string(f.data)
+		case valueType:
+			return walk(f.data, func(f field) error { // type
+				if f.num != typeTensor {
+					return nil
+				}
+				return walk(f.data, func(f field) error { // tensor type
+					if f.num != tensorShape {
+						return nil
+					}
+					return walk(f.data, func(f field) error { // shape
+						if f.num != shapeDim {
+							return nil
+						}
+						var dim int64
+						err := walk(f.data, func(f field) error {
+							if f.num == dimValue {
+								dim = int64(f.value)
+							}
+							return nil
+						})
+						t.dims = append(t.dims, dim)
+						return err
+					})
+				})
+			})
+		}
+		return nil
+	})
+	return t, err
+}
+
+// readEntry reads a key / value entry
+func readEntry(b []byte) (string, string, error) {
+	var key, value string
+	err := walk(b, func(f field) error {
+		switch f.num {
+		case entryKey:
+			key = string(f.data)
+		case entryValue:
+			value = string(f.data)
+		}
+		return nil
+	})
+	return key, value, err
+}
+
+// walk calls the function for each field of a message
+func walk(b []byte, fn func(f field) error) error {
+	fields, err := decode(b)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
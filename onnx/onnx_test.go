@@ -0,0 +1,181 @@
+package onnx
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// graphModel writes a model with the given graph content
+func graphModel(fn func(g *encoder)) []byte {
+	var model encoder
+	model.int(modelIRVersion, irVersion)
+	model.message(modelGraph, fn)
+	return model.buf
+}
+
+// writeTensor writes a float initializer
+func writeTensor(g *encoder, name string, dims []int64, values []float32) {
+	g.message(graphInitializer, func(m *encoder) {
+		m.packedInts(tensorDims, dims)
+		m.int(tensorDataType, typeFloat)
+		m.string(tensorName, name)
+		for _, v := range values {
+			m.float(tensorFloats, v) // not packed
+		}
+	})
+}
+
+// writeNode writes a node
+func writeNode(g *encoder, op string, output string, inputs ...string) {
+	g.message(graphNode, func(m *encoder) {
+		for _, input := range inputs {
+			m.string(nodeInput, input)
+		}
+		m.string(nodeOutput, output)
+		m.string(nodeOpType, op)
+		if op == "Gemm" {
+			m.message(nodeAttribute, func(a *encoder) {
+				a.string(attributeName, "transB")
+				a.int(attributeI, 1)
+				a.int(attributeType, attributeInt)
+			})
+			m.message(nodeAttribute, func(a *encoder) {
+				a.string(attributeName, "alpha")
+				a.float(attributeF, 1)
+				a.int(attributeType, attributeFloat)
+			})
+		}
+	})
+}
+
+func TestONNX(t *testing.T) {
+	Convey("onnx", t, func() {
+		rand.Seed(42)
+		net := mlp.NewNetwork(0.25, 3)
+		net.AddLayer(mlp.LinearBuilder{}, 5, mlp.Htan{})
+		net.AddLayer(mlp.LinearBuilder{}, 4, mlp.ReLU{})
+		net.AddLayer(mlp.LinearBuilder{}, 2, mlp.Sigmoid{})
+		x := []float64{0.3, -1.2, 2.5}
+
+		Convey("export and import", func() {
+			var buf bytes.Buffer
+			So(Export(&buf, net, Options{}), ShouldBeNil)
+			net2, err := Import(&buf)
+			So(err, ShouldBeNil)
+			So(net2.LearningRate(), ShouldEqual, 0.25)
+			So(net2.Neurons(), ShouldResemble, net.Neurons())
+			So(net2.Predict(x), ShouldResemble, net.Predict(x))
+		})
+
+		Convey("float32", func() {
+			var buf bytes.Buffer
+			So(Export(&buf, net, Options{Float32: true}), ShouldBeNil)
+			net2, err := Import(&buf)
+			So(err, ShouldBeNil)
+			y, y2 := net.Predict(x), net2.Predict(x)
+			for i := range y {
+				So(y2[i], ShouldAlmostEqual, y[i], 1e-5)
+			}
+		})
+
+		Convey("softmax", func() {
+			var buf bytes.Buffer
+			So(Export(&buf, net, Options{Softmax: true}), ShouldBeNil)
+			So(bytes.Contains(buf.Bytes(), []byte("Softmax")), ShouldBeTrue)
+			_, err := Import(&buf)
+			So(err, ShouldBeError, `node 6: operator "Softmax" is not supported`)
+		})
+
+		Convey("import gemm (transposed) and matmul + add", func() {
+			content := graphModel(func(g *encoder) {
+				writeNode(g, "Gemm", "h", "x", "w1", "b1")
+				writeNode(g, "Relu", "a", "h")
+				writeNode(g, "MatMul", "m", "a", "w2")
+				writeNode(g, "Add", "y", "b2", "m")
+				writeNode(g, "Sigmoid", "out", "y")
+				writeTensor(g, "w1", []int64{3, 2}, []float32{1, 2, 3, 4, 5, 6}) // [out, in]
+				writeTensor(g, "b1", []int64{3}, []float32{0, 0, -100})
+				writeTensor(g, "w2", []int64{3, 1}, []float32{1, 1, 1})
+				writeTensor(g, "b2", []int64{1, 1}, []float32{-10})
+				for _, name := range []string{"x", "w1"} { // initializers can be listed as inputs
+					g.message(graphInput, func(m *encoder) { writeValueInfo(m, name, typeFloat, 2) })
+				}
+				g.message(graphOutput, func(m *encoder) { writeValueInfo(m, "out", typeFloat, 1) })
+			})
+			net2, err := Import(bytes.NewReader(content))
+			So(err, ShouldBeNil)
+			So(net2.Neurons(), ShouldResemble, []int{2, 3, 1})
+			So(net2.LearningRate(), ShouldEqual, 0.1)
+
+			// h = (1*1+1*2, 1*3+1*4, relu(11-100)) = (3, 7, 0), y = sigmoid(10 - 10)
+			So(net2.Predict([]float64{1, 1}), ShouldResemble, []float64{0.5})
+		})
+
+		Convey("errors", func() {
+			So(Export(&bytes.Buffer{}, mlp.NewNetwork(0.1, 2), Options{}), ShouldBeError, "at least one layer expected")
+
+			_, err := Import(bytes.NewReader([]byte{0x0a, 0x10, 0x01}))
+			So(err, ShouldNotBeNil)
+
+			_, err = Import(bytes.NewReader(nil))
+			So(err, ShouldBeError, "graph expected")
+
+			content := graphModel(func(g *encoder) {
+				writeNode(g, "Gemm", "h", "x", "w1", "b1")
+				writeTensor(g, "w1", []int64{1, 1}, []float32{1})
+				writeTensor(g, "b1", []int64{1}, []float32{1})
+				g.message(graphInput, func(m *encoder) { writeValueInfo(m, "x", typeFloat, 1) })
+				g.message(graphOutput, func(m *encoder) { writeValueInfo(m, "h", typeFloat, 1) })
+			})
+			_, err = Import(bytes.NewReader(content))
+			So(err, ShouldBeError, "activator expected after the last linear layer")
+
+			content = graphModel(func(g *encoder) {
+				writeTensor(g, "w1", []int64{2, 2}, []float32{1})
+			})
+			_, err = Import(bytes.NewReader(content))
+			So(err, ShouldBeError, `tensor "w1": 1 values found, 4 expected`)
+
+			content = graphModel(func(g *encoder) {
+				writeTensor(g, "w1", []int64{-2, 2}, nil)
+			})
+			_, err = Import(bytes.NewReader(content))
+			So(err, ShouldBeError, `tensor "w1": invalid dimension -2`)
+
+			content = graphModel(func(g *encoder) {
+				writeTensor(g, "w1", []int64{1 << 40, 1 << 40}, nil)
+			})
+			_, err = Import(bytes.NewReader(content))
+			So(err, ShouldBeError, `tensor "w1": too many values`)
+
+			content = graphModel(func(g *encoder) {
+				writeNode(g, "Gemm", "h", "x", "w1", "b1")
+				writeNode(g, "Relu", "y", "h")
+				writeTensor(g, "w1", []int64{2, 0}, nil)
+				writeTensor(g, "b1", []int64{2}, []float32{0, 0})
+				g.message(graphInput, func(m *encoder) { writeValueInfo(m, "x", typeFloat, 2) })
+				g.message(graphOutput, func(m *encoder) { writeValueInfo(m, "y", typeFloat, 2) })
+			})
+			_, err = Import(bytes.NewReader(content))
+			So(err, ShouldBeError, "node 0 (Gemm): weights shall not be empty")
+
+			content = graphModel(func(g *encoder) {
+				g.message(graphNode, func(m *encoder) {
+					m.string(nodeOutput, "h")
+					m.string(nodeOpType, "Gemm")
+					m.message(nodeAttribute, func(a *encoder) {
+						a.string(attributeName, "alpha")
+						a.bytes(attributeF, nil) // empty packed floats
+					})
+				})
+			})
+			_, err = Import(bytes.NewReader(content))
+			So(err, ShouldBeError, `attribute "alpha": value expected`)
+		})
+	})
+}
@@ -0,0 +1,168 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// encoder writes protobuf fields
+type encoder struct {
+	buf []byte
+}
+
+// tag writes the field number and the wire type
+func (e *encoder) tag(field, wire int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wire))
+}
+
+// int writes a varint field
+func (e *encoder) int(field int, v int64) {
+	e.tag(field, wireVarint)
+	e.buf = binary.AppendUvarint(e.buf, uint64(v))
+}
+
+// float writes a 32 bits float field
+func (e *encoder) float(field int, v float32) {
+	e.tag(field, wireFixed32)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(v))
+}
+
+// bytes writes a length delimited field
+func (e *encoder) bytes(field int, b []byte) {
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// string writes a string field
+func (e *encoder) string(field int, s string) {
+	e.bytes(field, []byte(s))
+}
+
+// message writes an embedded message field
+func (e *encoder) message(field int, fn func(m *encoder)) {
+	var m encoder
+	fn(&m)
+	e.bytes(field, m.buf)
+}
+
+// packedInts writes a packed repeated varint field
+func (e *encoder) packedInts(field int, values []int64) {
+	var packed []byte
+	for _, v := range values {
+		packed = binary.AppendUvarint(packed, uint64(v))
+	}
+	e.bytes(field, packed)
+}
+
+// field read from a protobuf message
+type field struct {
+	num   int
+	wire  int
+	value uint64 // varint, fixed32 or fixed64 value
+	data  []byte // length delimited value
+}
+
+// decode splits a message into its fields
+func decode(b []byte) ([]field, error) {
+	var fields []field
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field key")
+		}
+		b = b[n:]
+		f := field{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case wireVarint:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return nil, fmt.Errorf("field %d: invalid varint", f.num)
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return nil, fmt.Errorf("field %d: unexpected end of message", f.num)
+			}
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return nil, fmt.Errorf("field %d: unexpected end of message", f.num)
+			}
+			f.value = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return nil, fmt.Errorf("field %d: unexpected end of message", f.num)
+			}
+			f.data = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			return nil, fmt.Errorf("field %d: unsupported wire type %d", f.num, f.wire)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// ints reads a repeated varint field (packed or not)
+func (f field) ints() ([]int64, error) {
+	if f.wire == wireVarint {
+		return []int64{int64(f.value)}, nil
+	}
+	var values []int64
+	for b := f.data; len(b) > 0; {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("field %d: invalid varint", f.num)
+		}
+		values = append(values, int64(v))
+		b = b[n:]
+	}
+	return values, nil
+}
+
+// floats reads a repeated float or double field (packed or not)
+func (f field) floats(double bool) ([]float64, error) {
+	switch {
+	case f.wire == wireFixed32 && !double:
+		return []float64{float64(math.Float32frombits(uint32(f.value)))}, nil
+	case f.wire == wireFixed64 && double:
+		return []float64{math.Float64frombits(f.value)}, nil
+	case f.wire == wireBytes:
+		return decodeFloats(f.data, double)
+	default:
+		return nil, fmt.Errorf("field %d: unexpected wire type %d", f.num, f.wire)
+	}
+}
+
+// decodeFloats reads little endian floats or doubles
+func decodeFloats(b []byte, double bool) ([]float64, error) {
+	size := 4
+	if double {
+		size = 8
+	}
+	if len(b)%size != 0 {
+		return nil, fmt.Errorf("invalid size %d of raw data", len(b))
+	}
+	values := make([]float64, len(b)/size)
+	for i := range values {
+		if double {
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[i*size:]))
+		} else {
+			values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b[i*size:])))
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,100 @@
+// Package prom exposes the training metrics of a network over http, using the prometheus text format
+package prom
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Exporter observes a training and serves its last metrics
+type Exporter struct {
+	namespace string // Prefix of the metric names
+
+	mu   sync.Mutex
+	last *mlp.Metrics // Metrics of the last epoch (nil if no epoch done)
+}
+
+// NewExporter builds an exporter whose metric names start with the namespace (default "simlpe")
+func NewExporter(namespace string) *Exporter {
+	if namespace == "" {
+		namespace = "simlpe"
+	}
+	return &Exporter{
+		namespace: namespace,
+	}
+}
+
+// Observe saves the metrics of an epoch (see mlp.Network.Observe)
+func (e *Exporter) Observe(m mlp.Metrics) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.last = &m
+}
+
+// metric to be exported
+type metric struct {
+	name  string
+	typ   string // gauge or counter
+	help  string
+	value float64
+}
+
+// metrics lists the exported values
+func (e *Exporter) metrics() []metric {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.last == nil {
+		return nil
+	}
+
+	m := e.last
+	samplesPerSecond := math.NaN() // Measured on the epoch only (resumed trainings included)
+	if m.EpochDuration > 0 {
+		samplesPerSecond = float64(m.Samples) / m.EpochDuration.Seconds()
+	}
+	return []metric{
+		{"epoch", "gauge", "Index of the last trained epoch (starting at 0).", float64(m.Epoch)},
+		{"training_seconds", "gauge", "Time elapsed since the training started.", m.Duration.Seconds()},
+		{"train_loss", "gauge", "Mean squared error of the last epoch.", m.MeanSquaredError},
+		{"validation_loss", "gauge", "Mean squared error on the validation data.", m.ValidationError},
+		{"validation_accuracy", "gauge", "Accuracy on the validation data.", m.ValidationAccuracy},
+		{"learning_rate", "gauge", "Learning rate of the last epoch.", m.LearningRate},
+		{"samples_per_second", "gauge", "Number of samples trained per second during the last epoch.", samplesPerSecond},
+		{"gradient_norm", "gauge", "Mean of the sample gradient norms of the last epoch.", m.GradientNorm},
+		{"non_finite_total", "counter", "Number of non finite sample errors.", float64(m.NonFinite)},
+	}
+}
+
+// ServeHTTP writes the last metrics using the prometheus text format
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	b := bufio.NewWriter(w)
+	for _, m := range e.metrics() {
+		name := e.namespace + "_" + m.name
+		fmt.Fprintf(b, "# HELP %s %s\n", name, m.help)
+		fmt.Fprintf(b, "# TYPE %s %s\n", name, m.typ)
+		fmt.Fprintf(b, "%s %s\n", name, formatFloat(m.value))
+	}
+	b.Flush()
+}
+
+// formatFloat formats a value (NaN, +Inf and -Inf included)
+func formatFloat(x float64) string {
+	switch {
+	case math.IsNaN(x):
+		return "NaN"
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,114 @@
+package prom
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// scrape reads the metrics served by the exporter
+func scrape(url string) (map[string]string, string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	values := map[string]string{}
+	for _, line := range strings.Split(string(body), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		values[fields[0]] = fields[1]
+	}
+	return values, resp.Header.Get("Content-Type"), nil
+}
+
+func TestExporter(t *testing.T) {
+	Convey("exporter", t, func() {
+		exp := NewExporter("")
+		server := httptest.NewServer(exp)
+		defer server.Close()
+
+		Convey("no epoch", func() {
+			values, contentType, err := scrape(server.URL)
+			So(err, ShouldBeNil)
+			So(values, ShouldBeEmpty)
+			So(contentType, ShouldStartWith, "text/plain; version=0.0.4")
+		})
+
+		Convey("observe", func() {
+			exp.Observe(mlp.Metrics{Epoch: 0, Duration: time.Second, EpochDuration: time.Second, Samples: 100})
+			exp.Observe(mlp.Metrics{
+				Epoch:              1,
+				Duration:           3 * time.Second,
+				EpochDuration:      2 * time.Second,
+				MeanSquaredError:   0.25,
+				GradientNorm:       1.5,
+				NonFinite:          2,
+				ValidationError:    math.NaN(),
+				ValidationAccuracy: math.NaN(),
+				Samples:            100,
+				LearningRate:       0.3,
+			})
+			values, _, err := scrape(server.URL)
+			So(err, ShouldBeNil)
+			So(values, ShouldResemble, map[string]string{
+				"simlpe_epoch":               "1",
+				"simlpe_training_seconds":    "3",
+				"simlpe_train_loss":          "0.25",
+				"simlpe_validation_loss":     "NaN",
+				"simlpe_validation_accuracy": "NaN",
+				"simlpe_learning_rate":       "0.3",
+				"simlpe_samples_per_second":  "50",
+				"simlpe_gradient_norm":       "1.5",
+				"simlpe_non_finite_total":    "2",
+			})
+		})
+
+		Convey("resumed training", func() {
+			// First epoch observed after a resume: the duration includes the epochs already done
+			exp.Observe(mlp.Metrics{Epoch: 10, Duration: 100 * time.Second, EpochDuration: 4 * time.Second, Samples: 100})
+			values, _, err := scrape(server.URL)
+			So(err, ShouldBeNil)
+			So(values["simlpe_samples_per_second"], ShouldEqual, "25")
+		})
+
+		Convey("training", func() {
+			net := mlp.NewNetwork(0.3, 2)
+			net.AddLayer(mlp.LinearBuilder{}, 3, mlp.Sigmoid{})
+			net.AddLayer(mlp.LinearBuilder{}, 1, mlp.Sigmoid{})
+			net.SetValidation([][]float64{{0, 0}}, [][]float64{{0}})
+			net.Observe(exp)
+			net.Stop.OnEpoch(5)
+
+			_, err := net.Train(context.Background(), [][]float64{{0, 0}, {1, 0}, {0, 1}, {1, 1}}, [][]float64{{0}, {1}, {1}, {0}})
+			So(err, ShouldBeNil)
+			values, _, err := scrape(server.URL)
+			So(err, ShouldBeNil)
+			So(values["simlpe_epoch"], ShouldEqual, "5")
+			So(values["simlpe_learning_rate"], ShouldEqual, "0.3")
+			So(values["simlpe_validation_accuracy"], ShouldNotEqual, "NaN")
+		})
+
+		Convey("format", func() {
+			So(formatFloat(math.Inf(1)), ShouldEqual, "+Inf")
+			So(formatFloat(math.Inf(-1)), ShouldEqual, "-Inf")
+			So(formatFloat(1e-7), ShouldEqual, "1e-07")
+		})
+	})
+}
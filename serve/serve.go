@@ -0,0 +1,261 @@
+// Package serve exposes a saved network over http:
+//   - POST /predict computes the outputs of one input ({"input": [...]}) or a batch ({"inputs": [[...], ...]})
+//   - GET /healthz checks that a model is loaded
+//   - GET /model describes the loaded model
+//
+// The model file can be reloaded while serving, requests in progress keep using the previous model.
+package serve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Options of the server
+type Options struct {
+	Timeout  time.Duration // Max duration of a request (default 5s)
+	MaxBytes int64         // Max size of a request body (default 10MB)
+}
+
+// model loaded from a file
+type model struct {
+	net     mlp.Network
+	summary mlp.Summary
+	modTime time.Time // Modification time of the file
+	loaded  time.Time // Loading time
+}
+
+// Server serves the predictions of a model file
+type Server struct {
+	path  string
+	opts  Options
+	model atomic.Pointer[model]
+	mux   *http.ServeMux
+}
+
+// New loads the model file and builds the server
+func New(path string, opts Options) (*Server, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 10 << 20
+	}
+	s := &Server{
+		path: path,
+		opts: opts,
+		mux:  http.NewServeMux(),
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	s.mux.HandleFunc("/predict", s.predict)
+	s.mux.HandleFunc("/healthz", s.healthz)
+	s.mux.HandleFunc("/model", s.describe)
+	return s, nil
+}
+
+// Reload reads the model file again, the current model is kept if the file is invalid
+func (s *Server) Reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	net := mlp.Network{}
+	if err := net.UnmarshalJSON(content); err != nil {
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	if len(net.Layers()) == 0 {
+		return fmt.Errorf("%s: at least one layer expected", s.path)
+	}
+
+	s.model.Store(&model{
+		net:     net,
+		summary: net.Summary(),
+		modTime: info.ModTime(),
+		loaded:  time.Now(),
+	})
+	return nil
+}
+
+// Watch reloads the model each time the file is modified, until the context is done.
+// Reload errors are sent to the optional callback.
+func (s *Server) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(s.path)
+		if err == nil && info.ModTime().Equal(s.model.Load().modTime) {
+			continue
+		}
+		if err == nil {
+			err = s.Reload()
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// predictRequest holds one input or a batch of inputs
+type predictRequest struct {
+	Input  []float64   `json:"input,omitempty"`
+	Inputs [][]float64 `json:"inputs,omitempty"`
+	K      int         `json:"k,omitempty"` // Number of best classes (if the model has labels)
+}
+
+// predictResponse holds the output of one input or the outputs of a batch
+type predictResponse struct {
+	Output      []float64          `json:"output,omitempty"`
+	Outputs     [][]float64        `json:"outputs,omitempty"`
+	Predictions []mlp.Prediction   `json:"predictions,omitempty"`
+	Batch       [][]mlp.Prediction `json:"batch-predictions,omitempty"`
+}
+
+// predict computes the outputs of the inputs
+func (s *Server) predict(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.opts.Timeout)
+	defer cancel()
+
+	var req predictRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.opts.MaxBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	m := s.model.Load() // same model for the whole request
+	single := req.Input != nil
+	inputs := req.Inputs
+	switch {
+	case single && inputs != nil:
+		writeError(w, http.StatusBadRequest, errors.New("either input or inputs expected"))
+		return
+	case single:
+		inputs = [][]float64{req.Input}
+	case len(inputs) == 0:
+		writeError(w, http.StatusBadRequest, errors.New("at least one input expected"))
+		return
+	}
+	if req.K > 0 && m.net.Labels() == nil {
+		writeError(w, http.StatusBadRequest, errors.New("the model has no labels"))
+		return
+	}
+
+	outputs := make([][]float64, len(inputs))
+	var predictions [][]mlp.Prediction
+	for i, x := range inputs {
+		if err := ctx.Err(); err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		if len(x) != m.summary.Inputs {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("input %d: %d values found, %d expected", i, len(x), m.summary.Inputs))
+			return
+		}
+		outputs[i] = m.net.Predict(x)
+		if req.K > 0 {
+			best, err := m.net.Labels().Decode(outputs[i], req.K)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			predictions = append(predictions, best)
+		}
+	}
+
+	var resp predictResponse
+	if single {
+		resp.Output = outputs[0]
+		if predictions != nil {
+			resp.Predictions = predictions[0]
+		}
+	} else {
+		resp.Outputs = outputs
+		resp.Batch = predictions
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// healthz checks that a model is loaded
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	if s.model.Load() == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("no model loaded"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// modelResponse describes the loaded model
+type modelResponse struct {
+	Path       string             `json:"path"`
+	Loaded     time.Time          `json:"loaded"`
+	Inputs     int                `json:"inputs"`
+	Outputs    int                `json:"outputs"`
+	Parameters int                `json:"parameters"`
+	Layers     []mlp.LayerSummary `json:"layers"`
+	Labels     []string           `json:"labels,omitempty"`
+}
+
+// describe writes the metadata of the loaded model
+func (s *Server) describe(w http.ResponseWriter, r *http.Request) {
+	m := s.model.Load()
+	resp := modelResponse{
+		Path:       s.path,
+		Loaded:     m.loaded,
+		Inputs:     m.summary.Inputs,
+		Outputs:    m.summary.Outputs,
+		Parameters: m.summary.Parameters,
+		Layers:     m.summary.Layers,
+	}
+	if labels := m.net.Labels(); labels != nil {
+		resp.Labels = labels.Classes()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeJSON writes a json response, or an internal error if the value cannot be encoded (non finite outputs)
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// writeError writes a json error
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,212 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// writeModel saves a new network with the given number of inputs
+func writeModel(path string, inputs int, labels *mlp.LabelEncoder) error {
+	net := mlp.NewNetwork(0.3, inputs)
+	net.AddLayer(mlp.LinearBuilder{}, 3, mlp.Sigmoid{})
+	net.AddLayer(mlp.LinearBuilder{}, 2, mlp.Sigmoid{})
+	if labels != nil {
+		net.SetLabels(labels)
+	}
+	content, err := net.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// call sends a request and decodes the json response
+func call(url, method, body string, resp interface{}) (int, error) {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if err != nil {
+		return 0, err
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Body.Close()
+	return r.StatusCode, json.NewDecoder(r.Body).Decode(resp)
+}
+
+func TestServer(t *testing.T) {
+	Convey("server", t, func() {
+		rand.Seed(42)
+		path := filepath.Join(t.TempDir(), "model.json")
+		labels, err := mlp.NewLabelEncoder("cat", "dog")
+		So(err, ShouldBeNil)
+		So(writeModel(path, 2, labels), ShouldBeNil)
+
+		srv, err := New(path, Options{})
+		So(err, ShouldBeNil)
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+
+		Convey("predict", func() {
+			var single predictResponse
+			status, err := call(ts.URL+"/predict", http.MethodPost, `{"input": [0, 1], "k": 1}`, &single)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, http.StatusOK)
+			So(single.Output, ShouldHaveLength, 2)
+			So(single.Predictions, ShouldHaveLength, 1)
+
+			var batch predictResponse
+			status, err = call(ts.URL+"/predict", http.MethodPost, `{"inputs": [[0, 1], [1, 0]]}`, &batch)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, http.StatusOK)
+			So(batch.Outputs, ShouldHaveLength, 2)
+			So(batch.Outputs[0], ShouldResemble, single.Output)
+			So(batch.Batch, ShouldBeNil)
+		})
+
+		Convey("invalid requests", func() {
+			requests := map[string]string{
+				`{"input": [0, 1, 2]}`:               "input 0: 3 values found, 2 expected",
+				`{"inputs": [[0, 1], [1]]}`:          "input 1: 1 values found, 2 expected",
+				`{"inputs": []}`:                     "at least one input expected",
+				`{"input": [0, 1], "inputs": [[1]]}`: "either input or inputs expected",
+				`{"unknown": 1}`:                     `json: unknown field "unknown"`,
+			}
+			for body, expected := range requests {
+				var resp map[string]string
+				status, err := call(ts.URL+"/predict", http.MethodPost, body, &resp)
+				So(err, ShouldBeNil)
+				So(status, ShouldEqual, http.StatusBadRequest)
+				So(resp["error"], ShouldEqual, expected)
+			}
+
+			var resp map[string]string
+			status, err := call(ts.URL+"/predict", http.MethodGet, "", &resp)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, http.StatusMethodNotAllowed)
+		})
+
+		Convey("timeout", func() {
+			srv.opts.Timeout = time.Nanosecond
+			var resp map[string]string
+			status, err := call(ts.URL+"/predict", http.MethodPost, `{"input": [0, 1]}`, &resp)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, http.StatusServiceUnavailable)
+			So(resp["error"], ShouldEqual, context.DeadlineExceeded.Error())
+		})
+
+		Convey("healthz and model", func() {
+			var health map[string]string
+			status, err := call(ts.URL+"/healthz", http.MethodGet, "", &health)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, http.StatusOK)
+			So(health["status"], ShouldEqual, "ok")
+
+			var desc modelResponse
+			status, err = call(ts.URL+"/model", http.MethodGet, "", &desc)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, http.StatusOK)
+			So(desc.Inputs, ShouldEqual, 2)
+			So(desc.Outputs, ShouldEqual, 2)
+			So(desc.Parameters, ShouldEqual, 17)
+			So(desc.Layers, ShouldHaveLength, 4)
+			So(desc.Labels, ShouldResemble, []string{"cat", "dog"})
+		})
+
+		Convey("hot reload", func() {
+			// Requests in progress during the reloads
+			var wg sync.WaitGroup
+			failures := make(chan int, 100)
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 20; j++ {
+						var resp predictResponse
+						status, err := call(ts.URL+"/predict", http.MethodPost, `{"inputs": [[0, 1]]}`, &resp)
+						if err != nil || (status != http.StatusOK && status != http.StatusBadRequest) {
+							failures <- status
+						}
+					}
+				}()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			var watchErr error
+			go func() {
+				srv.Watch(ctx, time.Millisecond, func(err error) { watchErr = err })
+				close(done)
+			}()
+
+			So(writeModel(path, 3, nil), ShouldBeNil)
+			os.Chtimes(path, time.Now(), time.Now().Add(time.Second)) // make sure the modification time changes
+			So(waitFor(func() bool { return srv.model.Load().summary.Inputs == 3 }), ShouldBeTrue)
+			wg.Wait()
+			cancel()
+			<-done
+			So(watchErr, ShouldBeNil)
+			So(failures, ShouldBeEmpty)
+
+			// Layers without neurons, or not matching the inputs, keep the current model
+			var content map[string]json.RawMessage
+			data, err := os.ReadFile(path)
+			So(err, ShouldBeNil)
+			So(json.Unmarshal(data, &content), ShouldBeNil)
+			for _, neurons := range []string{`null`, `[5, 3, 2]`} {
+				content["neurons"] = json.RawMessage(neurons)
+				data, err := json.Marshal(content)
+				So(err, ShouldBeNil)
+				So(os.WriteFile(path, data, 0o644), ShouldBeNil)
+				So(srv.Reload(), ShouldNotBeNil)
+				So(srv.model.Load().summary.Inputs, ShouldEqual, 3)
+			}
+
+			// An invalid file keeps the current model
+			So(os.WriteFile(path, []byte("{"), 0o644), ShouldBeNil)
+			So(srv.Reload(), ShouldNotBeNil)
+			So(srv.model.Load().summary.Inputs, ShouldEqual, 3)
+		})
+
+		Convey("errors", func() {
+			_, err := New(filepath.Join(t.TempDir(), "missing.json"), Options{})
+			So(err, ShouldNotBeNil)
+
+			So(os.WriteFile(path, []byte(`{"learning-rate": 0.3}`), 0o644), ShouldBeNil)
+			_, err = New(path, Options{})
+			So(err, ShouldNotBeNil)
+
+			// Non finite outputs cannot be encoded
+			rec := httptest.NewRecorder()
+			writeJSON(rec, http.StatusOK, predictResponse{Output: []float64{math.NaN()}})
+			So(rec.Code, ShouldEqual, http.StatusInternalServerError)
+			var resp map[string]string
+			So(json.NewDecoder(rec.Body).Decode(&resp), ShouldBeNil)
+			So(resp["error"], ShouldEqual, "json: unsupported value: NaN")
+		})
+	})
+}
+
+// waitFor waits until the condition is true (max 1 second)
+func waitFor(cond func() bool) bool {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return false
+}
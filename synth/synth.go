@@ -0,0 +1,130 @@
+// Package synth generates small benchmark datasets (inputs and outputs ready to train a network)
+//
+// Each generator returns n shuffled samples, noise being the standard deviation of a gaussian noise
+// added to the inputs. The same seed always generates the same samples.
+package synth
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Moons generates two interleaving half circles in 2D, the output is the moon (0 or 1)
+func Moons(n int, noise float64, seed int64) ([][]float64, [][]float64) {
+	r := rand.New(rand.NewSource(seed))
+	xData := make([][]float64, n)
+	yData := make([][]float64, n)
+	for i := range xData {
+		class := i % 2
+		t := math.Pi * r.Float64()
+		if class == 0 {
+			xData[i] = []float64{math.Cos(t), math.Sin(t)}
+		} else {
+			xData[i] = []float64{1 - math.Cos(t), 0.5 - math.Sin(t)}
+		}
+		yData[i] = []float64{float64(class)}
+	}
+	return finish(r, xData, yData, noise)
+}
+
+// Circles generates two concentric circles in 2D (radius 1 and 0.5), the output is 1 for the inner circle
+func Circles(n int, noise float64, seed int64) ([][]float64, [][]float64) {
+	r := rand.New(rand.NewSource(seed))
+	xData := make([][]float64, n)
+	yData := make([][]float64, n)
+	for i := range xData {
+		class := i % 2
+		radius := 1 - 0.5*float64(class)
+		t := 2 * math.Pi * r.Float64()
+		xData[i] = []float64{radius * math.Cos(t), radius * math.Sin(t)}
+		yData[i] = []float64{float64(class)}
+	}
+	return finish(r, xData, yData, noise)
+}
+
+// Spirals generates interleaving spiral arms in 2D (within the unit disk), the output is the one-hot arm.
+// At least one arm is generated.
+func Spirals(n, arms int, noise float64, seed int64) ([][]float64, [][]float64) {
+	arms = max(arms, 1)
+	r := rand.New(rand.NewSource(seed))
+	xData := make([][]float64, n)
+	yData := make([][]float64, n)
+	for i := range xData {
+		arm := i % arms
+		radius := r.Float64()
+		t := 3*math.Pi*radius + 2*math.Pi*float64(arm)/float64(arms) // 1.5 turn
+		xData[i] = []float64{radius * math.Cos(t), radius * math.Sin(t)}
+		yData[i] = oneHot(arm, arms)
+	}
+	return finish(r, xData, yData, noise)
+}
+
+// Blobs generates gaussian blobs in 2D whose centers are evenly placed on the unit circle,
+// the output is the one-hot blob. At least one blob is generated.
+func Blobs(n, centers int, noise float64, seed int64) ([][]float64, [][]float64) {
+	centers = max(centers, 1)
+	r := rand.New(rand.NewSource(seed))
+	xData := make([][]float64, n)
+	yData := make([][]float64, n)
+	for i := range xData {
+		center := i % centers
+		t := 2 * math.Pi * float64(center) / float64(centers)
+		xData[i] = []float64{math.Cos(t), math.Sin(t)}
+		yData[i] = oneHot(center, centers)
+	}
+	return finish(r, xData, yData, noise)
+}
+
+// Sine generates a regression of y = sin(x) with x in [-pi, pi], the noise is added to the output
+func Sine(n int, noise float64, seed int64) ([][]float64, [][]float64) {
+	r := rand.New(rand.NewSource(seed))
+	xData := make([][]float64, n)
+	yData := make([][]float64, n)
+	for i := range xData {
+		x := math.Pi * (2*r.Float64() - 1)
+		xData[i] = []float64{x}
+		yData[i] = []float64{math.Sin(x) + noise*r.NormFloat64()}
+	}
+	return xData, yData
+}
+
+// Parity generates random bits (n-bit xor), the output is 1 if the number of 1 bits is odd
+func Parity(n, bits int, noise float64, seed int64) ([][]float64, [][]float64) {
+	r := rand.New(rand.NewSource(seed))
+	xData := make([][]float64, n)
+	yData := make([][]float64, n)
+	for i := range xData {
+		x := make([]float64, bits)
+		var ones int
+		for j := range x {
+			if r.Intn(2) == 1 {
+				x[j] = 1
+				ones++
+			}
+		}
+		xData[i] = x
+		yData[i] = []float64{float64(ones % 2)}
+	}
+	return finish(r, xData, yData, noise)
+}
+
+// finish adds the noise to the inputs and shuffles the samples
+func finish(r *rand.Rand, xData, yData [][]float64, noise float64) ([][]float64, [][]float64) {
+	for _, x := range xData {
+		for j := range x {
+			x[j] += noise * r.NormFloat64()
+		}
+	}
+	r.Shuffle(len(xData), func(i, j int) {
+		xData[i], xData[j] = xData[j], xData[i]
+		yData[i], yData[j] = yData[j], yData[i]
+	})
+	return xData, yData
+}
+
+// oneHot builds an output of k values, the ith one being 1
+func oneHot(i, k int) []float64 {
+	y := make([]float64, k)
+	y[i] = 1
+	return y
+}
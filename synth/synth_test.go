@@ -0,0 +1,99 @@
+package synth
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// generator with default parameters
+type generator func(n int, noise float64, seed int64) ([][]float64, [][]float64)
+
+// train a small network and returns its accuracy
+func train(xData, yData [][]float64, activator mlp.Activator, epochs int) float64 {
+	rand.Seed(42)
+	net := mlp.NewNetwork(0.1, len(xData[0]))
+	net.AddLayer(mlp.LinearBuilder{}, 16, mlp.Htan{})
+	net.AddLayer(mlp.LinearBuilder{}, len(yData[0]), activator)
+	net.Stop.OnEpoch(epochs)
+	_, err := net.Train(context.Background(), xData, yData)
+	So(err, ShouldBeNil)
+	eval, err := net.Evaluate(xData, yData)
+	So(err, ShouldBeNil)
+	return eval.Accuracy
+}
+
+func TestSynth(t *testing.T) {
+	Convey("synth", t, func() {
+		generators := map[string]generator{
+			"moons":   Moons,
+			"circles": Circles,
+			"spirals": func(n int, noise float64, seed int64) ([][]float64, [][]float64) { return Spirals(n, 3, noise, seed) },
+			"blobs":   func(n int, noise float64, seed int64) ([][]float64, [][]float64) { return Blobs(n, 4, noise, seed) },
+			"sine":    Sine,
+			"parity":  func(n int, noise float64, seed int64) ([][]float64, [][]float64) { return Parity(n, 3, noise, seed) },
+		}
+
+		Convey("shape and seed", func() {
+			for name, gen := range generators {
+				Convey(name, func() {
+					x1, y1 := gen(50, 0.1, 1)
+					x2, y2 := gen(50, 0.1, 1)
+					x3, _ := gen(50, 0.1, 2)
+					So(x1, ShouldHaveLength, 50)
+					So(y1, ShouldHaveLength, 50)
+					So(x1, ShouldResemble, x2)
+					So(y1, ShouldResemble, y2)
+					So(x1, ShouldNotResemble, x3)
+				})
+			}
+		})
+
+		Convey("classes", func() {
+			_, y := Spirals(90, 3, 0, 1)
+			counts := make([]int, 3)
+			for _, yi := range y {
+				So(yi, ShouldHaveLength, 3)
+				for j, v := range yi {
+					if v == 1 {
+						counts[j]++
+					}
+				}
+			}
+			So(counts, ShouldResemble, []int{30, 30, 30})
+
+			// At least one arm or blob
+			for _, gen := range []func(n, classes int, noise float64, seed int64) ([][]float64, [][]float64){Spirals, Blobs} {
+				_, y = gen(5, 0, 0, 1)
+				So(y, ShouldResemble, [][]float64{{1}, {1}, {1}, {1}, {1}})
+			}
+
+			x, y := Circles(10, 0, 1)
+			for i, xi := range x {
+				radius := math.Hypot(xi[0], xi[1])
+				So(radius, ShouldAlmostEqual, 1-0.5*y[i][0])
+			}
+
+			x, y = Parity(20, 4, 0, 1)
+			for i, xi := range x {
+				So(int(xi[0]+xi[1]+xi[2]+xi[3])%2, ShouldEqual, y[i][0])
+			}
+		})
+
+		Convey("train", func() {
+			x, y := Moons(200, 0.1, 1)
+			So(train(x, y, mlp.Sigmoid{}, 200), ShouldBeGreaterThan, 0.95)
+
+			x, y = Circles(200, 0.05, 1)
+			So(train(x, y, mlp.Sigmoid{}, 200), ShouldBeGreaterThan, 0.95)
+
+			x, y = Blobs(200, 4, 0.2, 1)
+			So(train(x, y, mlp.Sigmoid{}, 100), ShouldBeGreaterThan, 0.95)
+		})
+	})
+}
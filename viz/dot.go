@@ -0,0 +1,46 @@
+package viz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// DOT writes the network as a graphviz graph, from left (inputs) to right (outputs)
+func DOT(w io.Writer, net mlp.Network, opts Options) error {
+	g, err := newGraph(net, opts)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph network {")
+	fmt.Fprintln(b, "  rankdir=LR;")
+	fmt.Fprintln(b, "  splines=line;")
+	fmt.Fprintln(b, `  node [shape=circle, label="", width=0.3];`)
+	for c, col := range g.columns {
+		fmt.Fprintf(b, "  subgraph cluster_%d {\n", c)
+		fmt.Fprintf(b, "    label=%q;\n", col.label())
+		fmt.Fprintln(b, "    color=lightgrey;")
+		for _, i := range col.shown {
+			fmt.Fprintf(b, "    n%d_%d;\n", c, i)
+		}
+		if col.collapsed() > 0 {
+			fmt.Fprintf(b, "    n%d_more [shape=plaintext, label=\"... %d more\"];\n", c, col.collapsed())
+		}
+		fmt.Fprintln(b, "  }")
+	}
+	for c, weights := range g.weights {
+		for _, i := range g.columns[c].shown {
+			for _, j := range g.columns[c+1].shown {
+				wij := weights[i][j]
+				fmt.Fprintf(b, "  n%d_%d -> n%d_%d [color=%q, penwidth=%.2f, arrowhead=none];\n",
+					c, i, c+1, j, color(wij), width(wij, g.max[c]))
+			}
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
@@ -0,0 +1,111 @@
+// Package viz draws a network as a graphviz DOT graph or as a standalone SVG image:
+// neurons of each layer, activators and connections colored by the weight sign
+// (blue if positive, red if negative) and sized by the weight magnitude
+package viz
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Options of the drawing
+type Options struct {
+	MaxNeurons int // Layers with more neurons are collapsed (default 16)
+}
+
+// column of neurons
+type column struct {
+	name      string
+	size      int    // Number of neurons
+	shown     []int  // Index of the drawn neurons
+	activator string // Activation function (if any)
+}
+
+// collapsed is the number of hidden neurons
+func (col column) collapsed() int {
+	return col.size - len(col.shown)
+}
+
+// graph of the network
+type graph struct {
+	columns []column
+	weights [][][]float64 // Weights between column i and column i+1
+	max     []float64     // Max absolute weight between column i and column i+1
+}
+
+// newGraph reads the layers of the network
+func newGraph(net mlp.Network, opts Options) (graph, error) {
+	maxNeurons := opts.MaxNeurons
+	if maxNeurons <= 0 {
+		maxNeurons = 16
+	}
+	neurons := net.Neurons()
+	if len(neurons) == 0 {
+		return graph{}, fmt.Errorf("empty network")
+	}
+
+	g := graph{
+		columns: []column{newColumn("input", neurons[0], maxNeurons)},
+	}
+	for _, layer := range net.Layers() {
+		switch l := layer.(type) {
+		case mlp.Linear:
+			weights := l.Weights()
+			_, out := l.Shape()
+			var max float64
+			for _, row := range weights {
+				for _, w := range row {
+					max = math.Max(max, math.Abs(w))
+				}
+			}
+			g.weights = append(g.weights, weights)
+			g.max = append(g.max, max)
+			g.columns = append(g.columns, newColumn(fmt.Sprintf("layer %d", len(g.columns)), out, maxNeurons))
+		case mlp.ActivatorLayer:
+			g.columns[len(g.columns)-1].activator = l.Activator().String()
+		default:
+			return graph{}, fmt.Errorf("cannot draw layer %q", layer.Type())
+		}
+	}
+	return g, nil
+}
+
+// newColumn selects the first and the last neurons of large layers
+func newColumn(name string, size, maxNeurons int) column {
+	col := column{
+		name: name,
+		size: size,
+	}
+	for i := 0; i < size; i++ {
+		if size <= maxNeurons || i < maxNeurons/2 || i >= size-(maxNeurons-maxNeurons/2) {
+			col.shown = append(col.shown, i)
+		}
+	}
+	return col
+}
+
+// label of a column
+func (col column) label() string {
+	if col.activator == "" {
+		return fmt.Sprintf("%s (%d)", col.name, col.size)
+	}
+	return fmt.Sprintf("%s (%d) %s", col.name, col.size, col.activator)
+}
+
+// color of a weight
+func color(w float64) string {
+	if w < 0 {
+		return "#d6604d"
+	}
+	return "#4393c3"
+}
+
+// width of a weight, relative to the max weight: from 0.1 to 3
+func width(w, max float64) float64 {
+	if max == 0 {
+		return 0.1
+	}
+	return 0.1 + 2.9*math.Abs(w)/max
+}
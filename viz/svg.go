@@ -0,0 +1,92 @@
+package viz
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+
+	"github.com/sbiemont/simlpe/mlp"
+)
+
+// Drawing sizes in pixels
+const (
+	columnWidth  = 180
+	neuronHeight = 28
+	radius       = 9
+	margin       = 40
+)
+
+// SVG writes the network as a standalone svg image, from left (inputs) to right (outputs)
+func SVG(w io.Writer, net mlp.Network, opts Options) error {
+	g, err := newGraph(net, opts)
+	if err != nil {
+		return err
+	}
+
+	// Rows of each column (collapsed neurons use one row)
+	rows := 0
+	for _, col := range g.columns {
+		if n := col.rows(); n > rows {
+			rows = n
+		}
+	}
+	imageWidth := 2*margin + (len(g.columns)-1)*columnWidth
+	imageHeight := 2*margin + rows*neuronHeight
+
+	// Position of a neuron
+	position := func(c, k int) (int, int) {
+		offset := (rows - g.columns[c].rows()) * neuronHeight / 2
+		return margin + c*columnWidth, margin + offset + k*neuronHeight + neuronHeight/2
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		imageWidth, imageHeight, imageWidth, imageHeight)
+	fmt.Fprintf(b, `<rect width="%d" height="%d" fill="white"/>`+"\n", imageWidth, imageHeight)
+
+	// Connections
+	for c, weights := range g.weights {
+		for ki, i := range g.columns[c].shown {
+			for kj, j := range g.columns[c+1].shown {
+				x1, y1 := position(c, g.columns[c].row(ki))
+				x2, y2 := position(c+1, g.columns[c+1].row(kj))
+				wij := weights[i][j]
+				fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%.2f" stroke-opacity="0.6"/>`+"\n",
+					x1, y1, x2, y2, color(wij), width(wij, g.max[c]))
+			}
+		}
+	}
+
+	// Neurons and labels
+	for c, col := range g.columns {
+		x, _ := position(c, 0)
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", x, margin/2, html.EscapeString(col.label()))
+		for k := range col.shown {
+			_, y := position(c, col.row(k))
+			fmt.Fprintf(b, `<circle cx="%d" cy="%d" r="%d" fill="white" stroke="black"/>`+"\n", x, y, radius)
+		}
+		if col.collapsed() > 0 {
+			_, y := position(c, len(col.shown)/2)
+			fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle">... %d more</text>`+"\n", x, y+4, col.collapsed())
+		}
+	}
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+// rows is the number of drawn rows, the collapsed neurons taking one row
+func (col column) rows() int {
+	if col.collapsed() > 0 {
+		return len(col.shown) + 1
+	}
+	return len(col.shown)
+}
+
+// row of the kth drawn neuron, skipping the collapsed row in the middle
+func (col column) row(k int) int {
+	if col.collapsed() > 0 && k >= len(col.shown)/2 {
+		return k + 1
+	}
+	return k
+}
@@ -0,0 +1,94 @@
+package viz
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/sbiemont/simlpe/mlp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// elements counts the svg elements by name, checking that the content is well formed
+func elements(content []byte) (map[string]int, error) {
+	counts := map[string]int{}
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return counts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
+
+func TestViz(t *testing.T) {
+	Convey("viz", t, func() {
+		rand.Seed(42)
+		net := mlp.NewNetwork(0.3, 2)
+		net.AddLayer(mlp.LinearBuilder{}, 3, mlp.Sigmoid{})
+		net.AddLayer(mlp.LinearBuilder{}, 1, mlp.Htan{})
+
+		Convey("dot", func() {
+			var buf bytes.Buffer
+			So(DOT(&buf, net, Options{}), ShouldBeNil)
+			dot := buf.String()
+			So(dot, ShouldStartWith, "digraph network {\n")
+			So(dot, ShouldContainSubstring, `label="input (2)";`)
+			So(dot, ShouldContainSubstring, `label="layer 1 (3) sigmoid";`)
+			So(dot, ShouldContainSubstring, `label="layer 2 (1) htan";`)
+			So(strings.Count(dot, "->"), ShouldEqual, 2*3+3*1)
+
+			// Sign of the weight
+			w := net.Layers()[0].(mlp.Linear).Weights()[0][0]
+			edge := dot[strings.Index(dot, "n0_0 -> n1_0"):]
+			edge = edge[:strings.Index(edge, "\n")]
+			So(edge, ShouldContainSubstring, color(w))
+		})
+
+		Convey("svg", func() {
+			var buf bytes.Buffer
+			So(SVG(&buf, net, Options{}), ShouldBeNil)
+			counts, err := elements(buf.Bytes())
+			So(err, ShouldBeNil)
+			So(counts["svg"], ShouldEqual, 1)
+			So(counts["circle"], ShouldEqual, 2+3+1)
+			So(counts["line"], ShouldEqual, 2*3+3*1)
+			So(counts["text"], ShouldEqual, 3)
+		})
+
+		Convey("collapse large layers", func() {
+			large := mlp.NewNetwork(0.3, 784)
+			large.AddLayer(mlp.LinearBuilder{}, 40, mlp.Sigmoid{})
+			large.AddLayer(mlp.LinearBuilder{}, 10, mlp.Sigmoid{})
+
+			var buf bytes.Buffer
+			So(SVG(&buf, large, Options{MaxNeurons: 8}), ShouldBeNil)
+			counts, err := elements(buf.Bytes())
+			So(err, ShouldBeNil)
+			So(counts["circle"], ShouldEqual, 8+8+8)
+			So(counts["line"], ShouldEqual, 8*8+8*8)
+			So(counts["text"], ShouldEqual, 3+3)
+			So(buf.String(), ShouldContainSubstring, "... 776 more")
+
+			buf.Reset()
+			So(DOT(&buf, large, Options{}), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, `n0_more [shape=plaintext, label="... 768 more"];`)
+			So(buf.String(), ShouldContainSubstring, "n0_783 -> n1_39")
+			So(buf.String(), ShouldNotContainSubstring, "n0_8 ")
+		})
+
+		Convey("empty network", func() {
+			So(DOT(io.Discard, mlp.Network{}, Options{}), ShouldBeError, "empty network")
+		})
+	})
+}